@@ -0,0 +1,66 @@
+package db
+
+import (
+	"math"
+	"time"
+)
+
+// AverageByMonth computes the mean net amount per month and key over the full span
+// of the database. Months without transactions count as zero. The first and last
+// month are usually only partially covered: they are left out unless prorate is set,
+// in which case they count by the fraction of their days covered. Spans of one or two
+// months are always prorated. Splits count towards their keys. The returned months are the divisor used.
+func AverageByMonth(database Database, keyFn func(Transaction) string, prorate bool) (map[string]Value, float64) {
+	averages := make(map[string]Value)
+	if database.Size() == 0 {
+		return averages, 0
+	}
+	first, last := database.Transactions[0].Date, database.Transactions[0].Date
+	for _, transact := range database.Transactions {
+		if transact.Date.Before(first) {
+			first = transact.Date
+		}
+		if transact.Date.After(last) {
+			last = transact.Date
+		}
+	}
+	span := (last.Year()-first.Year())*12 + int(last.Month()-first.Month()) + 1
+	prorate = prorate || span <= 2
+	months := float64(span - 2)
+	if prorate {
+		months = coveredMonths(first, last, span)
+	}
+	groups := make(map[string][]Transaction)
+	for _, transact := range SplitParts(database.Transactions) {
+		key := keyFn(transact)
+		groups[key] = append(groups[key], transact)
+	}
+	for key, ts := range groups {
+		var sum Value
+		for _, month := range SummarizeByMonth(Database{Transactions: ts}) {
+			edge := month.Year == first.Year() && month.Month == first.Month() || month.Year == last.Year() && month.Month == last.Month()
+			if edge && !prorate {
+				continue
+			}
+			sum = sum.Add(month.Net)
+		}
+		averages[key] = Value(math.Round(float64(sum) / months))
+	}
+	return averages, months
+}
+
+// coveredMonths counts the months between first and last, with the edge months
+// weighted by the fraction of their days covered.
+func coveredMonths(first, last time.Time, span int) float64 {
+	if span == 1 {
+		return float64(last.Day()-first.Day()+1) / float64(daysIn(first))
+	}
+	head := float64(daysIn(first)-first.Day()+1) / float64(daysIn(first))
+	tail := float64(last.Day()) / float64(daysIn(last))
+	return float64(span-2) + head + tail
+}
+
+// daysIn returns the number of days in the month of t.
+func daysIn(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+}
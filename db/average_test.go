@@ -0,0 +1,59 @@
+package db
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestAverageByMonthSplits(t *testing.T) {
+	receipt := NewTransaction("Market", Withdraw, 3000, time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC))
+	receipt.Category = "Food"
+	receipt.Splits = []Split{{"Food", 2000}, {"Household", 1000}}
+	database := NewDatabase("test")
+	database.Store(receipt)
+	database.Store(NewTransaction("Rent", Withdraw, 50000, time.Date(2016, 3, 31, 0, 0, 0, 0, time.UTC)))
+	averages, months := AverageByMonth(database, func(t Transaction) string { return t.Category }, false)
+	if months != 1 {
+		t.Fatalf("got %v months, want 1", months)
+	}
+	for category, want := range map[string]Value{"Food": -2000, "Household": -1000, "": -50000} {
+		if averages[category] != want {
+			t.Errorf("%s: got %v, want %v", category, averages[category], want)
+		}
+	}
+}
+
+func TestAverageByMonthTwoYears(t *testing.T) {
+	database := NewDatabase("test")
+	for month := 0; month < 24; month++ {
+		rent := NewTransaction("Rent", Withdraw, 50000, time.Date(2015, time.January+time.Month(month), 15, 0, 0, 0, 0, time.UTC))
+		rent.Category = "Home"
+		database.Store(rent)
+	}
+	food := NewTransaction("Market", Withdraw, 10000, time.Date(2015, 6, 1, 0, 0, 0, 0, time.UTC))
+	food.Category = "Food"
+	database.Store(food)
+	byCategory := func(t Transaction) string { return t.Category }
+	tests := []struct {
+		prorate bool
+		months  float64
+		want    map[string]Value
+	}{
+		// The partial first and last month are left out.
+		{false, 22, map[string]Value{"Home": -50000, "Food": -455}},
+		// The edge months count by the fraction of their days covered.
+		{true, 22 + 17.0/31 + 15.0/31, map[string]Value{"Home": -52101, "Food": -434}},
+	}
+	for _, test := range tests {
+		averages, months := AverageByMonth(database, byCategory, test.prorate)
+		if math.Abs(months-test.months) > 1e-9 {
+			t.Errorf("prorate %v: got %v months, want %v", test.prorate, months, test.months)
+		}
+		for category, want := range test.want {
+			if averages[category] != want {
+				t.Errorf("prorate %v, %s: got %v, want %v", test.prorate, category, averages[category], want)
+			}
+		}
+	}
+}
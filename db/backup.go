@@ -0,0 +1,33 @@
+package db
+
+import (
+	"fmt"
+)
+
+var (
+	// The backup contains invalid transactions.
+	errInvalidBackup = newClassError(ErrValidation, "invalid backup")
+)
+
+// Backup writes a copy of the existing database to dest.
+// The database is parsed first to make sure the copy is valid.
+func Backup(dest string) error {
+	database, err := Open()
+	if err != nil {
+		return err
+	}
+	return WriteFile(dest, database)
+}
+
+// RestoreFrom replaces the existing database with the backup at src.
+// Nothing is overwritten unless the backup parses and verifies.
+func RestoreFrom(src string) error {
+	database, err := OpenFile(src)
+	if err != nil {
+		return err
+	}
+	if problems := Verify(database); len(problems) > 0 {
+		return fmt.Errorf("%v: %w", errInvalidBackup, problems[0])
+	}
+	return Write(database)
+}
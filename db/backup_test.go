@@ -0,0 +1,62 @@
+package db
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestBackupRestore(t *testing.T) {
+	defer SetPath("")
+	path := newTestDatabase(t, ".trdb", 3)
+	dest := filepath.Join(t.TempDir(), "backup.trdb")
+	if err := Backup(dest); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(path, NewDatabase("test")); err != nil {
+		t.Fatal(err)
+	}
+	if err := RestoreFrom(dest); err != nil {
+		t.Fatal(err)
+	}
+	database, err := Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if database.Size() != 3 || database.Balance() != -750 {
+		t.Errorf("got %d transactions with balance %v after restoring, want 3 and -7.50", database.Size(), database.Balance())
+	}
+}
+
+func TestRestoreInvalidBackup(t *testing.T) {
+	defer SetPath("")
+	path := newTestDatabase(t, ".trdb", 1)
+	before, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalid := NewDatabase("broken")
+	invalid.Transactions = []Transaction{{Name: "", Type: Withdraw, Amount: 100}}
+	src := filepath.Join(t.TempDir(), "broken.trdb")
+	if err := WriteFile(src, invalid); err != nil {
+		t.Fatal(err)
+	}
+	if err := RestoreFrom(src); !errors.Is(err, ErrValidation) {
+		t.Errorf("got %v, want a validation error", err)
+	}
+	unparsable := filepath.Join(t.TempDir(), "garbage.trdb")
+	if err := ioutil.WriteFile(unparsable, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := RestoreFrom(unparsable); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("got %v, want a corrupt backup", err)
+	}
+	after, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(before) != string(after) {
+		t.Error("a failed restore changed the database")
+	}
+}
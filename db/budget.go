@@ -0,0 +1,87 @@
+package db
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+)
+
+const (
+	// The suffix of the companion file storing budget limits.
+	budgetSuffix = ".budget"
+)
+
+// BudgetLine compares the spendings of a category with its monthly limit.
+type BudgetLine struct {
+	Category  string
+	Spent     Value
+	Limit     Value
+	Remaining Value
+}
+
+// Over is true if the category exceeds its limit.
+func (b BudgetLine) Over() bool {
+	return b.Remaining < ZeroValue
+}
+
+// BudgetStatus computes the budget lines of all limited categories in the given month.
+// Withdrawals count as spendings, deposits (e.g. refunds) reduce them.
+// Splits count towards their categories.
+func BudgetStatus(database Database, limits map[string]Value, month time.Time) []BudgetLine {
+	spent := make(map[string]Value)
+	for _, transact := range SplitParts(database.Transactions) {
+		if transact.Date.Year() != month.Year() || transact.Date.Month() != month.Month() {
+			continue
+		}
+		switch transact.Type {
+		case Withdraw:
+			spent[transact.Category] = spent[transact.Category].Add(transact.Booked())
+		case Deposit:
+			spent[transact.Category] = spent[transact.Category].Sub(transact.Booked())
+		}
+	}
+	lines := make([]BudgetLine, 0, len(limits))
+	for category, limit := range limits {
+		lines = append(lines, BudgetLine{
+			Category:  category,
+			Spent:     spent[category],
+			Limit:     limit,
+			Remaining: limit.Sub(spent[category]),
+		})
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		return lines[i].Category < lines[j].Category
+	})
+	return lines
+}
+
+func budgetPath() string {
+	return databasePath + budgetSuffix
+}
+
+// OpenBudget reads the budget limits, a missing file means no limits.
+func OpenBudget() (map[string]Value, error) {
+	limits := make(map[string]Value)
+	bytes, err := ioutil.ReadFile(budgetPath())
+	if os.IsNotExist(err) {
+		return limits, nil
+	} else if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(bytes, &limits)
+	if err != nil {
+		return nil, err
+	}
+	return limits, nil
+}
+
+// WriteBudget stores the budget limits next to the database.
+func WriteBudget(limits map[string]Value) error {
+	json, err := json.Marshal(limits)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(budgetPath(), json, 0644)
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBudgetStatus(t *testing.T) {
+	march := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+	database := NewDatabase("test")
+	market := NewTransaction("Market", Withdraw, 3000, march)
+	market.Splits = []Split{{"Food", 2000}, {"Household", 1000}}
+	database.Store(market)
+	refund := NewTransaction("Refund", Deposit, 500, march.AddDate(0, 0, 1))
+	refund.Category = "Food"
+	database.Store(refund)
+	dinner := NewTransaction("Dinner", Withdraw, 4000, march.AddDate(0, 1, 0))
+	dinner.Category = "Food"
+	database.Store(dinner)
+	lines := BudgetStatus(database, map[string]Value{"Food": 1000, "Household": 1000, "Travel": 5000}, march)
+	want := []BudgetLine{
+		{"Food", 1500, 1000, -500},
+		{"Household", 1000, 1000, 0},
+		{"Travel", 0, 5000, 5000},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %+v, want %+v", lines, want)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("got %+v, want %+v", lines[i], want[i])
+		}
+	}
+	if !lines[0].Over() || lines[1].Over() {
+		t.Errorf("got over budget %v and %v, want only Food", lines[0].Over(), lines[1].Over())
+	}
+}
+
+func TestBudgetRoundTrip(t *testing.T) {
+	SetPath(filepath.Join(t.TempDir(), "test.trdb"))
+	defer SetPath("")
+	limits, err := OpenBudget()
+	if err != nil || len(limits) != 0 {
+		t.Fatalf("got %v, %v, want no limits without a budget file", limits, err)
+	}
+	if err := WriteBudget(map[string]Value{"Food": 30000}); err != nil {
+		t.Fatal(err)
+	}
+	limits, err = OpenBudget()
+	if err != nil || limits["Food"] != 30000 {
+		t.Errorf("got %v, %v, want the stored limit", limits, err)
+	}
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+var (
+	// No exchange rate between the currencies is known.
+	errMissingRate = newClassError(ErrValidation, "missing exchange rate")
+	// Exchange rates between currencies, keyed by "from>to".
+	rates = map[string]float64{}
+)
+
+func rateKey(from, to string) string {
+	return strings.ToLower(from) + ">" + strings.ToLower(to)
+}
+
+// SetRate registers how many major units of to one major unit of from is worth.
+func SetRate(from, to string, rate float64) {
+	rates[rateKey(from, to)] = rate
+}
+
+// Convert a value between currencies, ok is false if no rate is known.
+func Convert(v Value, from, to Currency) (Value, bool) {
+	if strings.EqualFold(from.Name, to.Name) {
+		return v, true
+	}
+	rate, ok := rates[rateKey(from.Name, to.Name)]
+	if !ok {
+		inverse, ok := rates[rateKey(to.Name, from.Name)]
+		if !ok || inverse == 0 {
+			return ZeroValue, false
+		}
+		rate = 1 / inverse
+	}
+	major := float64(v) / float64(from.Ratio) * rate
+	return Value(rounding.roundFloat(major * float64(to.Ratio))), true
+}
+
+// ConvertAll returns copies of the transactions with their amounts and splits converted
+// into the currency c, so they can be summed up. It fails if a rate is missing.
+func ConvertAll(ts []Transaction, c Currency) ([]Transaction, error) {
+	converted := make([]Transaction, len(ts))
+	for i, transact := range ts {
+		from := transact.CurrencyOf()
+		amount, ok := Convert(transact.Amount, from, c)
+		if !ok {
+			return nil, fmt.Errorf("%w from %s to %s", errMissingRate, from.Name, c.Name)
+		}
+		transact.Amount = amount
+		if len(transact.Splits) > 0 {
+			splits := make([]Split, len(transact.Splits))
+			for j, split := range transact.Splits {
+				split.Amount, _ = Convert(split.Amount, from, c)
+				splits[j] = split
+			}
+			transact.Splits = splits
+		}
+		transact.Currency = c.Name
+		converted[i] = transact
+	}
+	return converted, nil
+}
+
+// ConvertDatabase returns a copy of the database with all transactions converted into the currency c.
+func ConvertDatabase(database Database, c Currency) (Database, error) {
+	ts, err := ConvertAll(database.Transactions, c)
+	if err != nil {
+		return Database{}, err
+	}
+	database.Transactions = ts
+	database.recompute()
+	return database, nil
+}
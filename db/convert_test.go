@@ -0,0 +1,35 @@
+package db
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConvertDatabase(t *testing.T) {
+	date := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+	database := NewDatabase("test")
+	database.Store(NewTransaction("Salary", Deposit, 100000, date))
+	hotel := NewTransaction("Hotel", Withdraw, 10000, date)
+	hotel.Currency = Dollar.Name
+	hotel.Splits = []Split{{"Travel", 10000}}
+	database.Store(hotel)
+	if _, err := ConvertDatabase(database, Euro); !errors.Is(err, ErrValidation) {
+		t.Fatalf("without a rate: got %v, want validation error", err)
+	}
+	SetRate(Dollar.Name, Euro.Name, 0.5)
+	defer delete(rates, rateKey(Dollar.Name, Euro.Name))
+	converted, err := ConvertDatabase(database, Euro)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := converted.Balance(); got != 95000 {
+		t.Errorf("got balance %v, want 950.00", got)
+	}
+	if got := converted.Transactions[1]; got.Currency != Euro.Name || got.Splits[0].Amount != 5000 {
+		t.Errorf("got %+v, want the hotel in euro", got)
+	}
+	if database.Transactions[1].Splits[0].Amount != 10000 {
+		t.Error("converting changed the splits of the original")
+	}
+}
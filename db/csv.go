@@ -0,0 +1,44 @@
+package db
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CSVFormat selects the delimiter and decimal mark of exported CSV files.
+type CSVFormat struct {
+	Comma, DecimalMark rune
+}
+
+var (
+	// CSVEnglish separates fields by commas and uses a decimal point, as read by ImportCSV.
+	CSVEnglish = CSVFormat{',', '.'}
+	// CSVGerman separates fields by semicolons and uses a decimal comma.
+	CSVGerman = CSVFormat{';', ','}
+)
+
+// ExportCSV writes all transactions with a header row naming the columns
+// name, type, amount, date (YYYY-MM-DD), category, note, currency and reversal.
+// Fields containing the delimiter are quoted.
+func ExportCSV(w io.Writer, database Database, format CSVFormat) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = format.Comma
+	writer.Write([]string{"name", "type", "amount", "date", "category", "note", "currency", "reversal"})
+	for _, transact := range database.Transactions {
+		amount := transact.Amount.decimal(transact.CurrencyOf())
+		writer.Write([]string{
+			transact.Name,
+			string(transact.Type),
+			strings.Replace(amount, ".", string(format.DecimalMark), 1),
+			transact.Date.Format(csvDateLayout),
+			transact.Category,
+			transact.Note,
+			transact.Currency,
+			strconv.FormatBool(transact.Reversal),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
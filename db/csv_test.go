@@ -0,0 +1,51 @@
+package db
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func csvSample() Database {
+	date := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+	database := NewDatabase("test")
+	database.Store(NewTransaction("Salary", Deposit, 100000, date))
+	refund := NewTransaction("Coffee", Withdraw, 250, date)
+	refund.Reversal = true
+	refund.Note = "Wrong order, refunded"
+	database.Store(refund)
+	return database
+}
+
+func TestExportCSVRoundTrip(t *testing.T) {
+	database := csvSample()
+	var buf bytes.Buffer
+	if err := ExportCSV(&buf, database, CSVEnglish); err != nil {
+		t.Fatal(err)
+	}
+	ts, rejected, err := ParseCSV(&buf, Euro)
+	if err != nil || rejected != 0 {
+		t.Fatalf("got %d rejected, %v", rejected, err)
+	}
+	imported := NewDatabase("imported")
+	for _, transact := range ts {
+		imported.Store(transact)
+	}
+	if imported.Balance() != database.Balance() {
+		t.Errorf("got balance %v, want %v", imported.Balance(), database.Balance())
+	}
+	if !ts[1].Reversal || ts[1].Note != "Wrong order, refunded" {
+		t.Errorf("got %+v, want the reversal with its note", ts[1])
+	}
+}
+
+func TestExportCSVGerman(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportCSV(&buf, csvSample(), CSVGerman); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "Coffee;withdraw;2,50;2016-03-01;;Wrong order, refunded;Euro;true") {
+		t.Errorf("unexpected German export:\n%s", buf.String())
+	}
+}
@@ -1,43 +1,152 @@
 package db
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"math"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
 )
 
 const (
 	// The default database suffix.
 	defaultDatabaseSuffix = ".trdb"
+	// StdioPath reads the database from stdin and writes it to stdout.
+	StdioPath = "-"
 )
 
 var (
 	// Transaction could not be found (maybe invalid ID?)
-	errTransactionNotFound = errors.New("not found: the transaction does not exist")
+	errTransactionNotFound = newClassError(ErrNotFound, "not found: the transaction does not exist")
+	// Databases need a name.
+	errEmptyDatabaseName = newClassError(ErrValidation, "invalid database: empty name")
+	// Transaction validation failures.
+	errEmptyName      = newClassError(ErrValidation, "invalid transaction: empty name")
+	errInvalidAction  = newClassError(ErrValidation, "invalid transaction: unknown type")
+	errNegativeAmount = newClassError(ErrValidation, "invalid transaction: negative amount")
+	errMissingDate    = newClassError(ErrValidation, "invalid transaction: missing date")
+	// Amount is not a valid decimal number.
+	errInvalidAmount = newClassError(ErrValidation, "invalid amount: not a decimal number")
+	// Currency has not been registered.
+	errUnknownCurrency = newClassError(ErrValidation, "unknown currency")
+	// Databases read from stdin cannot be changed in place.
+	errStdinReadOnly = errors.New("read-only database: stdin cannot be modified in place")
+	// Looks up the home directory of the current user.
+	userHomeDir = os.UserHomeDir
 	// The default database storage path.
-	defaultDatabasePath = filepath.Join(os.Getenv("HOME"), defaultDatabaseSuffix)
+	defaultDatabasePath = defaultPath()
+	// The storage path of the active database.
+	databasePath = defaultDatabasePath
+	// Streams used for the StdioPath.
+	stdin  io.Reader = os.Stdin
+	stdout io.Writer = os.Stdout
+	// Contents of stdin, read on first use so the database can be opened repeatedly.
+	stdinBytes []byte
+	stdinRead  bool
+)
+
+// SymbolPosition places the currency symbol before or after the number.
+type SymbolPosition int
+
+const (
+	// SymbolSuffix writes the symbol after the number, e.g. 12.50€.
+	SymbolSuffix SymbolPosition = iota
+	// SymbolPrefix writes the symbol before the number, e.g. $12.50.
+	SymbolPrefix
 )
 
 // Currency stores information about a currency.
+// The Format receives the major units, the count of minor digits and the minor units.
+// The Separator replaces the decimal mark of the Format, a zero rune keeps it.
+// The Code is the ISO 4217 code used by bank statements.
 type Currency struct {
-	Name, Format string
-	Ratio        Value
+	Name, Format   string
+	Ratio          Value
+	Symbol         string
+	SymbolPosition SymbolPosition
+	Separator      rune
+	Code           string
+}
+
+// Matches checks if the name or ISO code denotes the currency (case insensitive).
+func (c Currency) Matches(name string) bool {
+	name = strings.TrimSpace(name)
+	return strings.EqualFold(name, c.Name) || (c.Code != "" && strings.EqualFold(name, c.Code))
+}
+
+// Digits returns the count of minor unit digits derived from the ratio.
+func (c Currency) Digits() int {
+	digits := 0
+	for r := c.Ratio; r > 1; r /= 10 {
+		digits++
+	}
+	return digits
+}
+
+// DecimalMark returns the separator between major and minor units.
+func (c Currency) DecimalMark() rune {
+	if c.Separator != 0 {
+		return c.Separator
+	}
+	if i := strings.Index(c.Format, "%0*d"); i > 0 {
+		return rune(c.Format[i-1])
+	}
+	return '.'
+}
+
+// numberFormat returns the Format with the decimal mark replaced by the Separator.
+func (c Currency) numberFormat() string {
+	i := strings.Index(c.Format, "%0*d")
+	if c.Separator == 0 || i <= 0 {
+		return c.Format
+	}
+	return c.Format[:i-1] + string(c.Separator) + c.Format[i:]
 }
 
 var (
 	// Euro currency
-	Euro = Currency{"Euro", "%d.%02d€", Value(100)}
+	Euro = Currency{"Euro", "%d.%0*d", Value(100), "€", SymbolSuffix, '.', "EUR"}
+	// GermanEuro is the euro written the German way with a decimal comma
+	GermanEuro = Currency{"EuroDE", "%d.%0*d", Value(100), "€", SymbolSuffix, ',', "EUR"}
 	// Dollar currency
-	Dollar = Currency{"Dollar", "%d.%02d$", Value(100)}
+	Dollar = Currency{"Dollar", "%d.%0*d", Value(100), "$", SymbolSuffix, '.', "USD"}
+	// USDollar is the dollar written the US way
+	USDollar = Currency{"USD", "%d.%0*d", Value(100), "$", SymbolPrefix, '.', "USD"}
 	// DefaultCurrency for display
 	DefaultCurrency = Euro
+	// All currencies available by name.
+	currencies = map[string]Currency{}
 )
 
-// Action is a transaction type.
+func init() {
+	RegisterCurrency(Euro)
+	RegisterCurrency(GermanEuro)
+	RegisterCurrency(Dollar)
+	RegisterCurrency(USDollar)
+}
+
+// RegisterCurrency makes the currency available for lookup by name.
+func RegisterCurrency(c Currency) {
+	currencies[strings.ToLower(c.Name)] = c
+}
+
+// LookupCurrency finds a registered currency by its name (case insensitive).
+func LookupCurrency(name string) (Currency, error) {
+	c, ok := currencies[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return Currency{}, fmt.Errorf("%w: %s", errUnknownCurrency, name)
+	}
+	return c, nil
+}
+
+// Action is a transaction type. Deposits add to the balance and withdrawals subtract,
+// unless the transaction is a reversal correcting an earlier one of the same type.
 type Action string
 
 const (
@@ -47,6 +156,18 @@ const (
 	Deposit Action = "deposit"
 )
 
+// ParseAction maps a transaction type alias like wd, dp, + or - to its action,
+// or "" if unknown.
+func ParseAction(text string) Action {
+	switch strings.ToLower(strings.TrimSpace(text)) {
+	case "wd", "withdraw", "draw", "-":
+		return Withdraw
+	case "dp", "deposit", "depo", "+":
+		return Deposit
+	}
+	return ""
+}
+
 // Value is a specific amount of money.
 type Value int
 
@@ -62,9 +183,38 @@ func abs(x Value) Value {
 	return x
 }
 
+// magnitude returns the absolute value, which unlike abs cannot overflow.
+func magnitude(x Value) uint64 {
+	if x < ZeroValue {
+		return -uint64(x)
+	}
+	return uint64(x)
+}
+
 // Stringifies the value in a currency format.
+// Negative values carry the minus sign in front of the whole amount.
 func (v Value) String() string {
-	return fmt.Sprintf(DefaultCurrency.Format, v/DefaultCurrency.Ratio, abs(v%DefaultCurrency.Ratio))
+	return v.Format(DefaultCurrency)
+}
+
+// Format stringifies the value in the format of the given currency.
+// Parse reads the result back into the same value if c is the default currency.
+func (v Value) Format(c Currency) string {
+	sign := ""
+	if v < ZeroValue {
+		sign = "-"
+	}
+	a, ratio := magnitude(v), uint64(c.Ratio)
+	var number string
+	if strings.Contains(c.Format, "%0*d") {
+		number = fmt.Sprintf(c.numberFormat(), a/ratio, c.Digits(), a%ratio)
+	} else {
+		number = fmt.Sprintf(c.Format, a/ratio)
+	}
+	if c.SymbolPosition == SymbolPrefix {
+		return sign + c.Symbol + number
+	}
+	return sign + number + c.Symbol
 }
 
 // Add more money onto the existing value.
@@ -72,6 +222,26 @@ func (v Value) Add(a Value) Value {
 	return v + a
 }
 
+// Sub takes money from the existing value.
+func (v Value) Sub(a Value) Value {
+	return v - a
+}
+
+// Neg flips the sign of the value.
+func (v Value) Neg() Value {
+	return -v
+}
+
+// Abs returns the magnitude of the value.
+func (v Value) Abs() Value {
+	return abs(v)
+}
+
+// Mul multiplies the value by an integer factor.
+func (v Value) Mul(factor int) Value {
+	return v * Value(factor)
+}
+
 // Smaller compares if the value is smaller than the argument.
 func (v Value) Smaller(a Value) bool {
 	return int(v) < int(a)
@@ -82,53 +252,394 @@ func (v Value) Larger(a Value) bool {
 	return int(v) > int(a)
 }
 
+// Float64 returns the value in major units of the default currency, e.g. 12.5 for 12.50€.
+// Floats cannot represent most decimal fractions exactly, so the result is only meant
+// for display and charts, never for further bookkeeping.
+func (v Value) Float64() float64 {
+	return float64(v) / float64(DefaultCurrency.Ratio)
+}
+
+// ValueFromFloat converts major units of the default currency into a value.
+// Fractions of minor units are rounded by the rounding mode, which also absorbs
+// float errors like 0.1+0.2 but cannot restore precision lost beyond about 15 digits.
+func ValueFromFloat(f float64) Value {
+	return Value(rounding.roundFloat(f * float64(DefaultCurrency.Ratio)))
+}
+
 // Parse a string into a pile of money.
+// Currency symbols are ignored, invalid amounts result in a ZeroValue.
 func Parse(in string) Value {
-	var maj, min int
-	fmt.Sscanf(in, DefaultCurrency.Format, &maj, &min)
-	return Value(Value(maj)*DefaultCurrency.Ratio + Value(min)%DefaultCurrency.Ratio)
+	value, err := ParseAmount(in)
+	if err != nil {
+		return ZeroValue
+	}
+	return value
+}
+
+// ParseAmount is like Parse but reports invalid amounts instead of reading them as zero.
+func ParseAmount(in string) (Value, error) {
+	number := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) || r == '-' || r == '+' || r == '.' || r == ',' {
+			return r
+		}
+		return -1
+	}, in)
+	return parseDecimal(normalizeSeparators(number, DefaultCurrency.DecimalMark()), DefaultCurrency)
+}
+
+// normalizeSeparators removes grouping separators and replaces the decimal mark by a dot.
+// If both '.' and ',' appear, the last one is the decimal mark. A single kind of separator
+// is the decimal mark if it matches the currency or does not group three digits,
+// e.g. 1,234.56 and 1.234,56 are equal in every currency while 12,50 reads as 12.50.
+func normalizeSeparators(s string, mark rune) string {
+	dot, comma := strings.LastIndex(s, "."), strings.LastIndex(s, ",")
+	decimal := byte(0)
+	switch {
+	case dot >= 0 && comma >= 0:
+		decimal = s[dot]
+		if comma > dot {
+			decimal = s[comma]
+		}
+	case dot >= 0 || comma >= 0:
+		last := dot
+		if comma >= 0 {
+			last = comma
+		}
+		single := strings.Count(s, s[last:last+1]) == 1
+		if single && (rune(s[last]) == mark || len(s)-last-1 != 3) {
+			decimal = s[last]
+		}
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case decimal != 0 && r == rune(decimal):
+			return '.'
+		case r == '.' || r == ',':
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// parseDecimal converts a plain decimal string like "-12.50" into minor units of the currency c.
+// Minor digits beyond the precision of the currency are rounded by the rounding mode.
+func parseDecimal(s string, c Currency) (Value, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimLeft(s, "+-")
+	// The magnitude of negative values may be one larger than the largest positive value.
+	limit, ratio := uint64(math.MaxInt64), uint64(c.Ratio)
+	if negative {
+		limit++
+	}
+	parts := strings.SplitN(s, ".", 2)
+	maj, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil || maj > limit/ratio {
+		return ZeroValue, errInvalidAmount
+	}
+	value := maj * ratio
+	if digits := c.Digits(); len(parts) == 2 && parts[1] != "" {
+		fraction := parts[1]
+		for len(fraction) < digits {
+			fraction += "0"
+		}
+		if digits > 0 {
+			min, err := strconv.ParseUint(fraction[:digits], 10, 64)
+			if err != nil || min > limit-value {
+				return ZeroValue, errInvalidAmount
+			}
+			value += min
+		}
+		rest := fraction[digits:]
+		if strings.TrimLeft(rest, "0123456789") != "" {
+			return ZeroValue, errInvalidAmount
+		}
+		if rounding.roundUp(Value(value), rest) {
+			if value == limit {
+				return ZeroValue, errInvalidAmount
+			}
+			value++
+		}
+	}
+	if negative {
+		return -Value(value), nil
+	}
+	return Value(value), nil
 }
 
 // Transaction stores a virtual transaction.
 type Transaction struct {
-	Name   string    `json:"name"`
-	Amount Value     `json:"amount"`
-	Type   Action    `json:"type"`
-	Date   time.Time `json:"date"`
+	Name     string    `json:"name"`
+	Amount   Value     `json:"amount"`
+	Type     Action    `json:"type"`
+	Date     time.Time `json:"date"`
+	Category string    `json:"category,omitempty"`
+	Tags     []string  `json:"tags,omitempty"`
+	Note     string    `json:"note,omitempty"`
+	// Allocations of the amount to several categories, empty if not split.
+	Splits []Split `json:"splits,omitempty"`
+	// Reversal inverts the effect of the type, e.g. a reversed deposit subtracts.
+	Reversal bool `json:"reversal,omitempty"`
+	// Name of the currency, empty for the default currency.
+	Currency string `json:"currency,omitempty"`
+	// Links both sides of a transfer between two databases.
+	TransferID string `json:"transfer,omitempty"`
+	// Financial institution ID of imported transactions.
+	FITID string `json:"fitid,omitempty"`
 }
 
 // NewTransaction initializes a new transaction.
+// Amounts are stored as their magnitude, only the action decides the sign.
 func NewTransaction(name string, action Action, amount Value, date time.Time) Transaction {
 	return Transaction{
 		Name:   name,
-		Amount: amount,
+		Amount: amount.Abs(),
 		Type:   action,
 		Date:   date,
 	}
 }
 
+// Signed returns the amount with the sign of its effect on the balance.
+// The sign of the stored amount is ignored.
+func (t Transaction) Signed() Value {
+	switch t.Type {
+	case Withdraw:
+		return t.Booked().Neg()
+	case Deposit:
+		return t.Booked()
+	}
+	return ZeroValue
+}
+
+// Booked returns the amount counted towards the deposits or withdrawals,
+// which is negative for reversals.
+func (t Transaction) Booked() Value {
+	if t.Reversal {
+		return t.Amount.Abs().Neg()
+	}
+	return t.Amount.Abs()
+}
+
+// CurrencyOf returns the currency of the transaction. Transactions read from or
+// stored in a database carry its book currency, others fall back to the euro.
+func (t Transaction) CurrencyOf() Currency {
+	currency, err := LookupCurrency(t.Currency)
+	if err != nil {
+		return Euro
+	}
+	return currency
+}
+
+// Validate checks the transaction for missing or invalid fields.
+func (t Transaction) Validate() error {
+	if problems := t.problems(); len(problems) > 0 {
+		return problems[0]
+	}
+	return nil
+}
+
+// ParseTags splits a comma-separated list into trimmed, non-empty tags.
+func ParseTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// HasTag checks if the transaction is tagged with the given tag.
+func (t Transaction) HasTag(tag string) bool {
+	for _, own := range t.Tags {
+		if own == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchTags checks if the transaction has all of the given tags,
+// or at least one of them if any is set. An empty list matches every transaction.
+func (t Transaction) MatchTags(tags []string, any bool) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	for _, tag := range tags {
+		if t.HasTag(tag) == any {
+			return any
+		}
+	}
+	return !any
+}
+
+// problems lists all missing or invalid fields of the transaction.
+func (t Transaction) problems() []error {
+	var problems []error
+	if strings.TrimSpace(t.Name) == "" {
+		problems = append(problems, errEmptyName)
+	}
+	if t.Type != Withdraw && t.Type != Deposit {
+		problems = append(problems, fmt.Errorf("%w '%s'", errInvalidAction, t.Type))
+	}
+	if t.Amount < ZeroValue {
+		problems = append(problems, errNegativeAmount)
+	}
+	if t.Date.IsZero() {
+		problems = append(problems, errMissingDate)
+	}
+	return append(problems, t.splitProblems()...)
+}
+
 // Database with a name and a list of transactions.
 type Database struct {
-	Name         string        `json:"name"`
+	Version int    `json:"version"`
+	Name    string `json:"name"`
+	// Name of the book currency, which transactions without a currency of their own are in.
+	Currency     string        `json:"currency,omitempty"`
 	Transactions []Transaction `json:"transaction"`
+	// Cached sum of all signed amounts, kept up to date by Store, Delete and Update.
+	balance Value
+	// Layout of the file the database was read from, kept when writing it back.
+	indented bool
 }
 
 // NewDatabase intializes a empty list of transactions.
 func NewDatabase(name string) Database {
 	return Database{
+		Version:      CurrentVersion,
 		Name:         name,
 		Transactions: make([]Transaction, 0),
 	}
 }
 
+// Clear removes all transactions but keeps the database name.
+func Clear(database *Database) {
+	database.Transactions = make([]Transaction, 0)
+	database.balance = ZeroValue
+}
+
+// Rename changes the name of the database.
+func Rename(database *Database, name string) error {
+	if strings.TrimSpace(name) == "" {
+		return errEmptyDatabaseName
+	}
+	database.Name = strings.TrimSpace(name)
+	return nil
+}
+
+// RenameCategory moves all transactions and splits of the old category into the new one
+// and returns the count of changed transactions.
+func RenameCategory(database *Database, old, new string) int {
+	changed := 0
+	for i := range database.Transactions {
+		transact := &database.Transactions[i]
+		matched := transact.Category == old
+		if matched {
+			transact.Category = new
+		}
+		for j := range transact.Splits {
+			if transact.Splits[j].Category == old {
+				transact.Splits[j].Category = new
+				matched = true
+			}
+		}
+		if matched {
+			changed++
+		}
+	}
+	return changed
+}
+
+// RenameName renames all transactions with the old name
+// and returns the count of changed transactions.
+func RenameName(database *Database, old, new string) int {
+	changed := 0
+	for i := range database.Transactions {
+		if database.Transactions[i].Name == old {
+			database.Transactions[i].Name = new
+			changed++
+		}
+	}
+	return changed
+}
+
+// BookCurrency returns the currency the database is kept in.
+// Databases without one predate book currencies and are kept in euro.
+func (db *Database) BookCurrency() Currency {
+	currency, err := LookupCurrency(db.Currency)
+	if err != nil {
+		return Euro
+	}
+	return currency
+}
+
+// adopt places a transaction without a currency in the book currency.
+func (db *Database) adopt(transact Transaction) Transaction {
+	if transact.Currency == "" {
+		transact.Currency = db.BookCurrency().Name
+	}
+	return transact
+}
+
 // Size returns the count of transactions.
 func (db *Database) Size() int {
 	return len(db.Transactions)
 }
 
+// Balance returns the sum of all deposits and withdrawals.
+// Amounts are summed as they are, see ConvertDatabase for books in several currencies.
+func (db *Database) Balance() Value {
+	return db.balance
+}
+
+// Each calls fn with every transaction in order of their IDs until fn returns false.
+func (db *Database) Each(fn func(id int, t Transaction) bool) {
+	for id, transact := range db.Transactions {
+		if !fn(id, transact) {
+			return
+		}
+	}
+}
+
+// recompute sums up the balance from scratch.
+func (db *Database) recompute() {
+	db.balance = ZeroValue
+	for _, transact := range db.Transactions {
+		db.balance = db.balance.Add(transact.Signed())
+	}
+}
+
+// FindDuplicate searches a transaction with the same name, amount, type and day.
+func FindDuplicate(database Database, t Transaction) (int, bool) {
+	for id, transact := range database.Transactions {
+		if transact.Name != t.Name || transact.Amount != t.Amount || transact.Type != t.Type || transact.Reversal != t.Reversal {
+			continue
+		}
+		y1, m1, d1 := transact.Date.Date()
+		y2, m2, d2 := t.Date.Date()
+		if y1 == y2 && m1 == m2 && d1 == d2 {
+			return id, true
+		}
+	}
+	return -1, false
+}
+
+// HasFITID checks if a transaction with the given institution ID exists.
+func (db *Database) HasFITID(ID string) bool {
+	for _, transact := range db.Transactions {
+		if ID != "" && transact.FITID == ID {
+			return true
+		}
+	}
+	return false
+}
+
 // Store the transaction in the database.
 func (db *Database) Store(transact Transaction) {
+	transact = db.adopt(transact)
 	db.Transactions = append(db.Transactions, transact)
+	db.balance = db.balance.Add(transact.Signed())
 }
 
 // Delete a transaction at the given position.
@@ -136,10 +647,22 @@ func (db *Database) Delete(ID int) error {
 	if ID < 0 || ID >= db.Size() {
 		return errTransactionNotFound
 	}
+	db.balance = db.balance.Sub(db.Transactions[ID].Signed())
 	db.Transactions = append(db.Transactions[:ID], db.Transactions[ID+1:]...)
 	return nil
 }
 
+// Update replaces the transaction at the given position.
+func (db *Database) Update(ID int, transact Transaction) error {
+	if ID < 0 || ID >= db.Size() {
+		return errTransactionNotFound
+	}
+	transact = db.adopt(transact)
+	db.balance = db.balance.Sub(db.Transactions[ID].Signed()).Add(transact.Signed())
+	db.Transactions[ID] = transact
+	return nil
+}
+
 // Retrieve a transaction from the database.
 func (db *Database) Read(ID int) (Transaction, error) {
 	if ID < 0 || ID >= db.Size() {
@@ -148,40 +671,126 @@ func (db *Database) Read(ID int) (Transaction, error) {
 	return db.Transactions[ID], nil
 }
 
+// defaultPath places the database in the home directory of the user,
+// falling back to the working directory if there is none.
+func defaultPath() string {
+	home, err := userHomeDir()
+	if err != nil || home == "" {
+		home = "."
+	}
+	return filepath.Join(home, defaultDatabaseSuffix)
+}
+
+// SetPath changes the storage path of the active database.
+// An empty path selects the default path.
+func SetPath(path string) {
+	if path == "" {
+		path = defaultDatabasePath
+	}
+	databasePath = path
+}
+
+// Path returns the storage path of the active database.
+func Path() string {
+	return databasePath
+}
+
 // Open a existing database.
 func Open() (Database, error) {
-	var database Database
+	return OpenFile(databasePath)
+}
 
-	bytes, err := ioutil.ReadFile(defaultDatabasePath)
+// OpenBookCurrency returns the book currency of the active database,
+// reading only the header of the JSON-lines format.
+func OpenBookCurrency() (Currency, error) {
+	database := Database{}
+	if isJSONLines(databasePath) && databasePath != StdioPath {
+		header, err := readLinesHeader(databasePath)
+		if err != nil {
+			return Currency{}, err
+		}
+		database.Currency = header.Currency
+	} else {
+		var err error
+		database, err = Open()
+		if err != nil {
+			return Currency{}, err
+		}
+	}
+	return database.BookCurrency(), nil
+}
+
+// OpenFile opens an existing database at the given path.
+func OpenFile(path string) (Database, error) {
+	if isJSONLines(path) {
+		return openLines(path)
+	}
+	bytes, err := readFile(path)
 	if err != nil {
 		return Database{}, err
 	}
-	err = json.Unmarshal(bytes, &database)
-	if err != nil {
-		return Database{}, nil
+	return Unmarshal(bytes)
+}
+
+// readFile reads the file at the given path or stdin for the StdioPath.
+func readFile(path string) ([]byte, error) {
+	if path != StdioPath {
+		return ioutil.ReadFile(path)
 	}
-	return database, nil
+	if !stdinRead {
+		bytes, err := ioutil.ReadAll(stdin)
+		if err != nil {
+			return nil, err
+		}
+		stdinBytes, stdinRead = bytes, true
+	}
+	return stdinBytes, nil
+}
+
+// IsReadOnly reports whether the error was caused by changing a database read from stdin.
+func IsReadOnly(err error) bool {
+	return err == errStdinReadOnly
 }
 
 // Exists is true if the database already exists.
 func Exists() bool {
-	if _, err := os.Stat(defaultDatabasePath); os.IsNotExist(err) {
+	if databasePath == StdioPath {
+		return true
+	}
+	if _, err := os.Stat(databasePath); os.IsNotExist(err) {
 		return false
 	}
 	return true
 }
 
 // Write the database to the hard drive.
+// Databases read from stdin are read-only.
 func Write(database Database) error {
-	json, err := json.Marshal(database)
+	if databasePath == StdioPath {
+		return errStdinReadOnly
+	}
+	return WriteFile(databasePath, database)
+}
+
+// WriteFile writes the database to the given path.
+func WriteFile(path string, database Database) error {
+	marshal := Marshal
+	if isJSONLines(path) {
+		marshal = marshalLines
+	}
+	json, err := marshal(database)
 	if err != nil {
 		return err
 	}
-	ioutil.WriteFile(defaultDatabasePath, json, 0644)
-	return nil
+	if path == StdioPath {
+		_, err = stdout.Write(json)
+		return err
+	}
+	return ioutil.WriteFile(path, json, 0644)
 }
 
 // Store the transaction in the existing database.
+// The whole database is read and rewritten, use Append to add a single transaction.
 func Store(transact Transaction) error {
 	database, err := Open()
 	if err != nil {
@@ -192,6 +801,36 @@ func Store(transact Transaction) error {
 	return err
 }
 
+// Append adds the transaction to the end of the existing database.
+// It is equivalent to Store, but leaves the cheapest way of appending to the storage format.
+func Append(transact Transaction) error {
+	if databasePath == StdioPath {
+		return errStdinReadOnly
+	}
+	return appendFile(databasePath, transact)
+}
+
+// appendFile adds a single transaction to the database at the given path.
+// The JSON format can only be appended to by reading and rewriting the whole file,
+// which costs time linear in the size of the database, while the JSON-lines format
+// only adds a line.
+func appendFile(path string, transact Transaction) error {
+	if isJSONLines(path) {
+		header, err := readLinesHeader(path)
+		if err != nil {
+			return err
+		}
+		book := Database{Currency: header.Currency}
+		return appendLine(path, newBookRecord(transact, book.BookCurrency()))
+	}
+	database, err := OpenFile(path)
+	if err != nil {
+		return err
+	}
+	database.Store(transact)
+	return WriteFile(path, database)
+}
+
 // Get a transaction from an existing database.
 func Get(ID int) (Transaction, error) {
 	database, err := Open()
@@ -202,6 +841,7 @@ func Get(ID int) (Transaction, error) {
 }
 
 // Delete a transaction from an existing database.
+// The JSON-lines format records a tombstone instead of rewriting the file.
 func Delete(ID int) error {
 	database, err := Open()
 	if err != nil {
@@ -211,6 +851,9 @@ func Delete(ID int) error {
 	if err != nil {
 		return err
 	}
+	if isJSONLines(databasePath) {
+		return appendLine(databasePath, lineTombstone{ID})
+	}
 	err = Write(database)
 	return err
 }
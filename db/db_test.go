@@ -0,0 +1,517 @@
+package db
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLookupCurrency(t *testing.T) {
+	for _, name := range []string{"Dollar", "dollar", " DOLLAR "} {
+		if got, err := LookupCurrency(name); err != nil || got != Dollar {
+			t.Errorf("%q: got %v, %v, want %v", name, got, err, Dollar)
+		}
+	}
+	if _, err := LookupCurrency("crown"); err == nil {
+		t.Error("unregistered currency: got no error")
+	}
+	crown := Currency{Name: "LookupCrown", Format: "%d.%0*d", Ratio: 100, Symbol: " kr"}
+	RegisterCurrency(crown)
+	if got, err := LookupCurrency("lookupcrown"); err != nil || got != crown {
+		t.Errorf("registered currency: got %v, %v, want %v", got, err, crown)
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in    string
+		want  Value
+		valid bool
+	}{
+		{"12.50", 1250, true},
+		{"-3,5", -350, true},
+		{"1.234,56€", 123456, true},
+		{"0", 0, true},
+		{"abc", 0, false},
+		{"", 0, false},
+		{"1.2.3,4.5", 0, false},
+	}
+	for _, test := range tests {
+		got, err := ParseAmount(test.in)
+		if (err == nil) != test.valid || got != test.want {
+			t.Errorf("ParseAmount(%q): got %v, %v, want %v, valid %v", test.in, got, err, test.want, test.valid)
+		}
+		if Parse(test.in) != test.want {
+			t.Errorf("Parse(%q): got %v, want %v", test.in, Parse(test.in), test.want)
+		}
+	}
+}
+
+func TestValueStringNegative(t *testing.T) {
+	tests := []struct {
+		v    Value
+		want string
+	}{
+		{-50, "-0.50€"},
+		{-150, "-1.50€"},
+		{-199, "-1.99€"},
+		{-1, "-0.01€"},
+		{50, "0.50€"},
+	}
+	for _, test := range tests {
+		if got := test.v.Format(Euro); got != test.want {
+			t.Errorf("Value(%d): got %s, want %s", int(test.v), got, test.want)
+		}
+		if back := Parse(test.v.Format(Euro)); back != test.v {
+			t.Errorf("Value(%d): parsed back as %d", int(test.v), int(back))
+		}
+	}
+}
+
+func TestThreeDecimalCurrency(t *testing.T) {
+	dinar := Currency{Name: "Dinar", Format: "%d.%0*d", Ratio: 1000, Symbol: " DT"}
+	if dinar.Digits() != 3 {
+		t.Errorf("got %d digits, want 3", dinar.Digits())
+	}
+	defer func(c Currency) { DefaultCurrency = c }(DefaultCurrency)
+	DefaultCurrency = dinar
+	tests := []struct {
+		v    Value
+		want string
+	}{
+		{12345, "12.345 DT"},
+		{5, "0.005 DT"},
+		{-1500, "-1.500 DT"},
+	}
+	for _, test := range tests {
+		if got := test.v.String(); got != test.want {
+			t.Errorf("Value(%d): got %s, want %s", int(test.v), got, test.want)
+		}
+		if back := Parse(test.want); back != test.v {
+			t.Errorf("%s: parsed as %d, want %d", test.want, int(back), int(test.v))
+		}
+	}
+	if got := Parse("1.5"); got != 1500 {
+		t.Errorf("1.5: parsed as %d, want 1500", int(got))
+	}
+}
+
+func TestFindDuplicate(t *testing.T) {
+	database := NewDatabase("test")
+	database.Store(NewTransaction("Salary", Deposit, 100000, time.Date(2016, 3, 1, 9, 0, 0, 0, time.UTC)))
+	database.Store(NewTransaction("Coffee", Withdraw, 250, time.Date(2016, 3, 1, 9, 0, 0, 0, time.UTC)))
+	tests := []struct {
+		t    Transaction
+		id   int
+		dupe bool
+	}{
+		{NewTransaction("Coffee", Withdraw, 250, time.Date(2016, 3, 1, 17, 0, 0, 0, time.UTC)), 1, true},
+		{NewTransaction("Coffee", Withdraw, 300, time.Date(2016, 3, 1, 9, 0, 0, 0, time.UTC)), -1, false},
+		{NewTransaction("Coffee", Deposit, 250, time.Date(2016, 3, 1, 9, 0, 0, 0, time.UTC)), -1, false},
+		{NewTransaction("Coffee", Withdraw, 250, time.Date(2016, 3, 2, 9, 0, 0, 0, time.UTC)), -1, false},
+		{NewTransaction("coffee", Withdraw, 250, time.Date(2016, 3, 1, 9, 0, 0, 0, time.UTC)), -1, false},
+	}
+	for _, test := range tests {
+		if id, dupe := FindDuplicate(database, test.t); id != test.id || dupe != test.dupe {
+			t.Errorf("%+v: got %d, %v, want %d, %v", test.t, id, dupe, test.id, test.dupe)
+		}
+	}
+}
+
+func TestValueArithmetic(t *testing.T) {
+	tests := []struct {
+		name      string
+		got, want Value
+	}{
+		{"add", Value(150).Add(-200), -50},
+		{"sub", Value(150).Sub(200), -50},
+		{"sub negative", Value(-150).Sub(-200), 50},
+		{"neg", Value(150).Neg(), -150},
+		{"neg negative", Value(-150).Neg(), 150},
+		{"neg zero", ZeroValue.Neg(), 0},
+		{"mul", Value(150).Mul(3), 450},
+		{"mul negative factor", Value(150).Mul(-2), -300},
+		{"mul negative value", Value(-150).Mul(-2), 300},
+		{"abs", Value(-150).Abs(), 150},
+	}
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%s: got %d, want %d", test.name, int(test.got), int(test.want))
+		}
+	}
+}
+
+// newTestDatabase writes a database with n transactions to a temporary file with
+// the given suffix and selects it as the current database.
+func newTestDatabase(tb testing.TB, suffix string, n int) string {
+	tb.Helper()
+	path := filepath.Join(tb.TempDir(), "test"+suffix)
+	database := NewDatabase("test")
+	for i := 0; i < n; i++ {
+		database.Store(NewTransaction("Coffee", Withdraw, 250, time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)))
+	}
+	if err := WriteFile(path, database); err != nil {
+		tb.Fatal(err)
+	}
+	SetPath(path)
+	return path
+}
+
+func TestAppend(t *testing.T) {
+	defer SetPath("")
+	for _, suffix := range []string{".trdb", JSONLinesSuffix} {
+		newTestDatabase(t, suffix, 2)
+		salary := NewTransaction("Salary", Deposit, 100000, time.Date(2016, 3, 2, 0, 0, 0, 0, time.UTC))
+		if err := Append(salary); err != nil {
+			t.Fatal(err)
+		}
+		database, err := Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if database.Size() != 3 || database.Transactions[2].Name != "Salary" || database.Balance() != 99500 {
+			t.Errorf("%s: got %+v after appending", suffix, database)
+		}
+	}
+}
+
+func BenchmarkAppend(b *testing.B) {
+	defer SetPath("")
+	for _, suffix := range []string{".trdb", JSONLinesSuffix} {
+		b.Run(suffix, func(b *testing.B) {
+			newTestDatabase(b, suffix, 1000)
+			transact := NewTransaction("Salary", Deposit, 100000, time.Date(2016, 3, 2, 0, 0, 0, 0, time.UTC))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := Append(transact); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultPath(t *testing.T) {
+	defer func(lookup func() (string, error)) { userHomeDir = lookup }(userHomeDir)
+	tests := []struct {
+		home string
+		err  error
+		want string
+	}{
+		{filepath.Join("home", "alice"), nil, filepath.Join("home", "alice", ".trdb")},
+		{"", nil, ".trdb"},
+		{"", errors.New("no home"), ".trdb"},
+	}
+	for _, test := range tests {
+		userHomeDir = func() (string, error) { return test.home, test.err }
+		if got := defaultPath(); got != test.want {
+			t.Errorf("home %q, %v: got %s, want %s", test.home, test.err, got, test.want)
+		}
+	}
+}
+
+func TestNormalizeSeparators(t *testing.T) {
+	tests := []struct {
+		in   string
+		mark rune
+		want string
+	}{
+		{"1,234.56", ',', "1234.56"},
+		{"1.234,56", '.', "1234.56"},
+		{"1,234,567", '.', "1234567"},
+		{"1.234.567,89", ',', "1234567.89"},
+		{"1,234", '.', "1234"},
+		{"1,234", ',', "1.234"},
+		{"12,50", '.', "12.50"},
+		{"1250", '.', "1250"},
+	}
+	for _, test := range tests {
+		if got := normalizeSeparators(test.in, test.mark); got != test.want {
+			t.Errorf("normalizeSeparators(%q, %q): got %q, want %q", test.in, test.mark, got, test.want)
+		}
+	}
+	for _, in := range []string{"1,234.56", "1.234,56", "1 234,56 €"} {
+		if got := Parse(in); got != 123456 {
+			t.Errorf("Parse(%q): got %v, want 123456", in, got)
+		}
+	}
+}
+
+func TestFormatSymbolPosition(t *testing.T) {
+	tests := []struct {
+		value    Value
+		currency Currency
+		want     string
+	}{
+		{1250, USDollar, "$12.50"},
+		{-1250, USDollar, "-$12.50"},
+		{5, USDollar, "$0.05"},
+		{1250, Euro, "12.50€"},
+		{-1250, Euro, "-12.50€"},
+	}
+	for _, test := range tests {
+		if got := test.value.Format(test.currency); got != test.want {
+			t.Errorf("%d in %s: got %s, want %s", test.value, test.currency.Name, got, test.want)
+		}
+	}
+}
+
+func TestBalanceCache(t *testing.T) {
+	date := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+	database := NewDatabase("test")
+	check := func(step string) {
+		t.Helper()
+		cached := database.Balance()
+		database.recompute()
+		if cached != database.Balance() {
+			t.Errorf("%s: got cached balance %v, want %v", step, cached, database.Balance())
+		}
+	}
+	database.Store(NewTransaction("Salary", Deposit, 100000, date))
+	database.Store(NewTransaction("Coffee", Withdraw, 250, date))
+	database.Store(NewTransaction("Rent", Withdraw, 50000, date))
+	check("store")
+	refund := NewTransaction("Coffee", Withdraw, 250, date)
+	refund.Reversal = true
+	if err := database.Update(1, refund); err != nil {
+		t.Fatal(err)
+	}
+	check("update")
+	if err := database.Delete(0); err != nil {
+		t.Fatal(err)
+	}
+	check("delete")
+	if err := database.Delete(5); err == nil {
+		t.Errorf("deleted a missing transaction")
+	}
+	check("failed delete")
+	data, err := Marshal(database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Balance() != -49750 {
+		t.Errorf("got balance %v after decoding, want -497.50", decoded.Balance())
+	}
+}
+
+func TestStdioPath(t *testing.T) {
+	defer func(r io.Reader, w io.Writer) { stdin, stdout, stdinBytes, stdinRead = r, w, nil, false }(stdin, stdout)
+	defer SetPath("")
+	database := NewDatabase("piped")
+	database.Store(NewTransaction("Salary", Deposit, 100000, time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)))
+	data, err := Marshal(database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	stdin, stdout, stdinBytes, stdinRead = bytes.NewReader(data), &out, nil, false
+	SetPath(StdioPath)
+	// Stdin is read once, so the database can be opened repeatedly.
+	for i := 0; i < 2; i++ {
+		opened, err := Open()
+		if err != nil || opened.Name != "piped" || opened.Size() != 1 {
+			t.Fatalf("open %d: got %+v, %v", i, opened, err)
+		}
+	}
+	if !Exists() {
+		t.Errorf("stdin does not exist")
+	}
+	if err := Write(database); !IsReadOnly(err) {
+		t.Errorf("write: got %v, want a read-only error", err)
+	}
+	if err := Append(database.Transactions[0]); !IsReadOnly(err) {
+		t.Errorf("append: got %v, want a read-only error", err)
+	}
+	if err := WriteFile(StdioPath, database); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.Bytes(), data) {
+		t.Errorf("got %s on stdout, want %s", out.Bytes(), data)
+	}
+}
+
+func TestRenameCategory(t *testing.T) {
+	date := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+	database := NewDatabase("test")
+	for _, category := range []string{"coffee", "Coffee", "coffee beans", "coffee"} {
+		transact := NewTransaction("Coffee", Withdraw, 250, date)
+		transact.Category = category
+		database.Store(transact)
+	}
+	receipt := NewTransaction("Market", Withdraw, 3000, date)
+	receipt.Category = "Food"
+	receipt.Splits = []Split{{"Food", 2000}, {"coffee", 1000}}
+	database.Store(receipt)
+	if changed := RenameCategory(&database, "coffee", "Coffee"); changed != 3 {
+		t.Errorf("got %d changed transactions, want 3", changed)
+	}
+	want := []string{"Coffee", "Coffee", "coffee beans", "Coffee", "Food"}
+	for i, transact := range database.Transactions {
+		if transact.Category != want[i] {
+			t.Errorf("transaction %d: got category %q, want %q", i, transact.Category, want[i])
+		}
+	}
+	if splits := database.Transactions[4].Splits; splits[0].Category != "Food" || splits[1].Category != "Coffee" {
+		t.Errorf("got splits %+v, want the coffee split renamed", splits)
+	}
+	if changed := RenameName(&database, "Coffee", "Espresso"); changed != 4 || database.Transactions[4].Name != "Market" {
+		t.Errorf("got %d renamed transactions, want 4 without the market", changed)
+	}
+}
+
+func TestFloatConversion(t *testing.T) {
+	tests := []struct {
+		value Value
+		float float64
+	}{
+		{0, 0},
+		{1, 0.01},
+		{99, 0.99},
+		{100, 1},
+		{101, 1.01},
+		{-1, -0.01},
+		{-100, -1},
+		{-1250, -12.5},
+	}
+	for _, test := range tests {
+		if got := test.value.Float64(); got != test.float {
+			t.Errorf("%d.Float64(): got %v, want %v", test.value, got, test.float)
+		}
+		if got := ValueFromFloat(test.float); got != test.value {
+			t.Errorf("ValueFromFloat(%v): got %d, want %d", test.float, got, test.value)
+		}
+	}
+	// The float error of 0.1+0.2 is absorbed by the rounding.
+	if got := ValueFromFloat(0.1 + 0.2); got != 30 {
+		t.Errorf("ValueFromFloat(0.1+0.2): got %d, want 30", got)
+	}
+}
+
+func TestGermanRoundTrip(t *testing.T) {
+	defer func(c Currency) { DefaultCurrency = c }(DefaultCurrency)
+	DefaultCurrency = GermanEuro
+	tests := []struct {
+		v    Value
+		want string
+	}{
+		{1250, "12,50€"},
+		{-1250, "-12,50€"},
+		{5, "0,05€"},
+		{123456789, "1234567,89€"},
+	}
+	for _, test := range tests {
+		if got := test.v.String(); got != test.want {
+			t.Errorf("Value(%d): got %s, want %s", int(test.v), got, test.want)
+		}
+		if back := Parse(test.v.String()); back != test.v {
+			t.Errorf("Value(%d): parsed back as %d", int(test.v), int(back))
+		}
+	}
+	// A single comma is the decimal mark, a single dot grouping three digits is not.
+	for in, want := range map[string]Value{"12,5": 1250, "1.234": 123400, "1.234,56": 123456} {
+		if got := Parse(in); got != want {
+			t.Errorf("Parse(%q): got %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestParseStringRoundTrip(t *testing.T) {
+	defer func(c Currency) { DefaultCurrency = c }(DefaultCurrency)
+	values := []Value{math.MinInt64, math.MinInt64 + 1, math.MaxInt64, math.MaxInt64 - 1}
+	for v := Value(-1000); v <= 1000; v++ {
+		values = append(values, v)
+	}
+	// Spread over all magnitudes, with and without a sign.
+	for v := Value(1); v < math.MaxInt64/7; v *= 7 {
+		values = append(values, v, -v, v+1, -v-1)
+	}
+	for _, currency := range []Currency{Euro, GermanEuro, USDollar} {
+		DefaultCurrency = currency
+		for _, v := range values {
+			if back, err := ParseAmount(v.String()); err != nil || back != v {
+				t.Errorf("%s: Value(%d) is %s, parsed back as %d, %v", currency.Name, int64(v), v, int64(back), err)
+			}
+		}
+	}
+}
+
+func TestReversalBalance(t *testing.T) {
+	date := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+	correction := func(action Action, amount Value) Transaction {
+		transact := NewTransaction("Correction", action, amount, date)
+		transact.Reversal = true
+		return transact
+	}
+	database := NewDatabase("test")
+	database.Store(NewTransaction("Salary", Deposit, 100000, date))
+	database.Store(NewTransaction("Coffee", Withdraw, 250, date))
+	tests := []struct {
+		transact       Transaction
+		signed, booked Value
+		balance        Value
+	}{
+		// A reversed deposit takes the wrongly booked salary back out.
+		{correction(Deposit, 10000), -10000, -10000, 89750},
+		// A reversed withdrawal refunds the coffee.
+		{correction(Withdraw, 250), 250, -250, 90000},
+	}
+	for _, test := range tests {
+		if signed, booked := test.transact.Signed(), test.transact.Booked(); signed != test.signed || booked != test.booked {
+			t.Errorf("reversed %s: got signed %v and booked %v, want %v and %v", test.transact.Type, signed, booked, test.signed, test.booked)
+		}
+		database.Store(test.transact)
+		if database.Balance() != test.balance {
+			t.Errorf("reversed %s: got balance %v, want %v", test.transact.Type, database.Balance(), test.balance)
+		}
+	}
+	if err := correction(Deposit, 10000).Validate(); err != nil {
+		t.Errorf("got %v for a valid correction", err)
+	}
+}
+
+func TestNewTransactionNormalizesAmount(t *testing.T) {
+	date := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, action := range []Action{Deposit, Withdraw} {
+		transact := NewTransaction("Coffee", action, -250, date)
+		if transact.Amount != 250 || transact.Type != action {
+			t.Errorf("%s: got %v as %s, want 250 as %s", action, transact.Amount, transact.Type, action)
+		}
+		if err := transact.Validate(); err != nil {
+			t.Errorf("%s: got %v", action, err)
+		}
+	}
+	negative := NewTransaction("Coffee", Withdraw, 250, date)
+	negative.Amount = -250
+	if err := negative.Validate(); !errors.Is(err, errNegativeAmount) {
+		t.Errorf("got %v, want a negative amount error", err)
+	}
+	database := NewDatabase("test")
+	database.Store(NewTransaction("Salary", Deposit, -100000, date))
+	database.Store(NewTransaction("Coffee", Withdraw, -250, date))
+	if database.Balance() != 99750 {
+		t.Errorf("got balance %v, want 997.50", database.Balance())
+	}
+}
+
+func TestEachStops(t *testing.T) {
+	database := NewDatabase("test")
+	for _, name := range []string{"Coffee", "Lunch", "Dinner"} {
+		database.Store(NewTransaction(name, Withdraw, 1000, time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)))
+	}
+	var visited []int
+	database.Each(func(id int, transact Transaction) bool {
+		visited = append(visited, id)
+		return transact.Name != "Lunch"
+	})
+	if len(visited) != 2 || visited[0] != 0 || visited[1] != 1 {
+		t.Errorf("visited %v, want to stop after #1", visited)
+	}
+}
@@ -0,0 +1,32 @@
+package db
+
+import "errors"
+
+// Classes of errors returned by the package, match them with errors.Is.
+var (
+	// ErrNotFound is returned if a transaction does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrCorrupt is returned if a stored database cannot be decoded.
+	ErrCorrupt = errors.New("corrupt database")
+	// ErrValidation is returned for invalid transactions, amounts and other input.
+	ErrValidation = errors.New("invalid")
+)
+
+// classError is an error of one of the error classes with its own message.
+type classError struct {
+	class   error
+	message string
+}
+
+func newClassError(class error, message string) error {
+	return classError{class, message}
+}
+
+func (e classError) Error() string {
+	return e.message
+}
+
+// Unwrap returns the class of the error.
+func (e classError) Unwrap() error {
+	return e.class
+}
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorClasses(t *testing.T) {
+	database := NewDatabase("test")
+	_, readErr := database.Read(3)
+	_, unmarshalErr := Unmarshal([]byte("{"))
+	_, parseErr := ParseAmount("abc")
+	_, currencyErr := LookupCurrency("XYZ")
+	tests := []struct {
+		name  string
+		err   error
+		class error
+	}{
+		{"read", readErr, ErrNotFound},
+		{"delete", database.Delete(0), ErrNotFound},
+		{"unmarshal", unmarshalErr, ErrCorrupt},
+		{"amount", parseErr, ErrValidation},
+		{"currency", currencyErr, ErrValidation},
+		{"rename", Rename(&database, " "), ErrValidation},
+		{"transaction", Transaction{}.Validate(), ErrValidation},
+		{"wrapped", fmt.Errorf("open: %w", errTransactionNotFound), ErrNotFound},
+	}
+	for _, test := range tests {
+		for _, class := range []error{ErrNotFound, ErrCorrupt, ErrValidation} {
+			if got := errors.Is(test.err, class); got != (class == test.class) {
+				t.Errorf("%s: errors.Is(%v, %v) got %v", test.name, test.err, class, got)
+			}
+		}
+	}
+}
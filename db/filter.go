@@ -0,0 +1,63 @@
+package db
+
+import (
+	"strings"
+	"time"
+)
+
+// FilterOptions select transactions, zero fields match every transaction.
+type FilterOptions struct {
+	// Name must equal the transaction name (case sensitive).
+	Name string
+	// Min and Max bound the amount (inclusive).
+	Min, Max *Value
+	// Around matches amounts within the Tolerance of it (inclusive).
+	Around    *Value
+	Tolerance Value
+	// Type must equal the transaction type.
+	Type Action
+	// From is the earliest date (inclusive), To the first date excluded.
+	// Dates compare by their wall clock, regardless of their location.
+	From, To time.Time
+	// Tags must all be present, or at least one of them if AnyTag is set.
+	Tags   []string
+	AnyTag bool
+	// Note must be contained in the note (case insensitive).
+	Note string
+}
+
+// Match checks if the transaction satisfies all options.
+func (opts FilterOptions) Match(t Transaction) bool {
+	switch {
+	case opts.Name != "" && t.Name != opts.Name:
+		return false
+	case opts.Max != nil && opts.Max.Smaller(t.Amount):
+		return false
+	case opts.Min != nil && opts.Min.Larger(t.Amount):
+		return false
+	case opts.Around != nil && (opts.Around.Sub(opts.Tolerance).Larger(t.Amount) || opts.Around.Add(opts.Tolerance).Smaller(t.Amount)):
+		return false
+	case !opts.From.IsZero() && WallClock(t.Date).Before(WallClock(opts.From)):
+		return false
+	case !opts.To.IsZero() && !WallClock(t.Date).Before(WallClock(opts.To)):
+		return false
+	case opts.Type != "" && t.Type != opts.Type:
+		return false
+	case !t.MatchTags(opts.Tags, opts.AnyTag):
+		return false
+	case opts.Note != "" && !strings.Contains(strings.ToLower(t.Note), strings.ToLower(opts.Note)):
+		return false
+	}
+	return true
+}
+
+// Filter returns the transactions matching the options in their original order.
+func Filter(ts []Transaction, opts FilterOptions) []Transaction {
+	var matches []Transaction
+	for _, transact := range ts {
+		if opts.Match(transact) {
+			matches = append(matches, transact)
+		}
+	}
+	return matches
+}
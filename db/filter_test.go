@@ -0,0 +1,59 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFilter(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2016, 3, d, 0, 0, 0, 0, time.UTC) }
+	salary := NewTransaction("Salary", Deposit, 100000, day(1))
+	coffee := NewTransaction("Coffee", Withdraw, 250, day(2))
+	coffee.Tags = []string{"food"}
+	coffee.Note = "With Alice"
+	rent := NewTransaction("Rent", Withdraw, 50000, day(3))
+	ts := []Transaction{salary, coffee, rent}
+	min, max, around, zero := Value(1000), Value(60000), Value(49000), Value(0)
+	tests := []struct {
+		name string
+		opts FilterOptions
+		want []string
+	}{
+		{"empty", FilterOptions{}, []string{"Salary", "Coffee", "Rent"}},
+		{"name", FilterOptions{Name: "Rent"}, []string{"Rent"}},
+		{"amount", FilterOptions{Min: &min, Max: &max}, []string{"Rent"}},
+		{"zero max", FilterOptions{Max: &zero}, nil},
+		{"around", FilterOptions{Around: &around, Tolerance: 1000}, []string{"Rent"}},
+		{"type", FilterOptions{Type: Withdraw}, []string{"Coffee", "Rent"}},
+		{"dates", FilterOptions{From: day(2), To: day(3)}, []string{"Coffee"}},
+		{"tags", FilterOptions{Tags: []string{"food"}}, []string{"Coffee"}},
+		{"note", FilterOptions{Note: "alice"}, []string{"Coffee"}},
+	}
+	for _, test := range tests {
+		got := Filter(ts, test.opts)
+		if len(got) != len(test.want) {
+			t.Errorf("%s: got %d transactions, want %v", test.name, len(got), test.want)
+			continue
+		}
+		for i := range got {
+			if got[i].Name != test.want[i] {
+				t.Errorf("%s: got %s at %d, want %s", test.name, got[i].Name, i, test.want[i])
+			}
+		}
+	}
+}
+
+func TestFilterAroundEdges(t *testing.T) {
+	around := Value(5000)
+	opts := FilterOptions{Around: &around, Tolerance: 500}
+	date := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+	for amount, want := range map[Value]bool{4499: false, 4500: true, 5000: true, 5500: true, 5501: false} {
+		if got := opts.Match(NewTransaction("Dinner", Withdraw, amount, date)); got != want {
+			t.Errorf("%v around %v ± %v: got %v, want %v", amount, around, opts.Tolerance, got, want)
+		}
+	}
+	exact := FilterOptions{Around: &around}
+	if !exact.Match(NewTransaction("Dinner", Withdraw, 5000, date)) || exact.Match(NewTransaction("Dinner", Withdraw, 5001, date)) {
+		t.Error("a zero tolerance must only match the exact amount")
+	}
+}
@@ -0,0 +1,178 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// The on-disk format is spelled out separately from the in-memory types,
+// so new fields only change the persisted shape once they are added here.
+
+// databaseRecord is the persisted shape of a Database.
+type databaseRecord struct {
+	Version      int                 `json:"version"`
+	Name         string              `json:"name"`
+	Currency     string              `json:"currency,omitempty"`
+	Transactions []transactionRecord `json:"transaction"`
+}
+
+// transactionRecord is the persisted shape of a Transaction.
+// Amounts are decimal strings in the currency of the transaction.
+type transactionRecord struct {
+	Name       string          `json:"name"`
+	Amount     json.RawMessage `json:"amount"`
+	Type       Action          `json:"type"`
+	Date       time.Time       `json:"date"`
+	Category   string          `json:"category,omitempty"`
+	Tags       []string        `json:"tags,omitempty"`
+	Note       string          `json:"note,omitempty"`
+	Splits     []splitRecord   `json:"splits,omitempty"`
+	Reversal   bool            `json:"reversal,omitempty"`
+	Currency   string          `json:"currency,omitempty"`
+	TransferID string          `json:"transfer,omitempty"`
+	FITID      string          `json:"fitid,omitempty"`
+}
+
+// splitRecord is the persisted shape of a Split.
+type splitRecord struct {
+	Category string          `json:"category"`
+	Amount   json.RawMessage `json:"amount"`
+}
+
+func newTransactionRecord(t Transaction) transactionRecord {
+	currency := t.CurrencyOf()
+	record := transactionRecord{
+		Name:       t.Name,
+		Amount:     marshalAmount(t.Amount, currency),
+		Type:       t.Type,
+		Date:       t.Date,
+		Category:   t.Category,
+		Tags:       t.Tags,
+		Note:       t.Note,
+		Reversal:   t.Reversal,
+		Currency:   t.Currency,
+		TransferID: t.TransferID,
+		FITID:      t.FITID,
+	}
+	for _, split := range t.Splits {
+		record.Splits = append(record.Splits, splitRecord{split.Category, marshalAmount(split.Amount, currency)})
+	}
+	return record
+}
+
+// newBookRecord is the persisted shape of a transaction in a database kept in the
+// book currency, which is left out for transactions in it.
+func newBookRecord(t Transaction, book Currency) transactionRecord {
+	if t.Currency == "" {
+		t.Currency = book.Name
+	}
+	record := newTransactionRecord(t)
+	if strings.EqualFold(record.Currency, book.Name) {
+		record.Currency = ""
+	}
+	return record
+}
+
+// transaction decodes the record, which is in the book currency unless it names its own.
+func (r transactionRecord) transaction(book Currency) (Transaction, error) {
+	t := Transaction{
+		Name:       r.Name,
+		Type:       r.Type,
+		Date:       r.Date,
+		Category:   r.Category,
+		Tags:       r.Tags,
+		Note:       r.Note,
+		Reversal:   r.Reversal,
+		Currency:   r.Currency,
+		TransferID: r.TransferID,
+		FITID:      r.FITID,
+	}
+	if t.Currency == "" {
+		t.Currency = book.Name
+	}
+	currency := t.CurrencyOf()
+	var err error
+	t.Amount, err = unmarshalAmount(r.Amount, currency)
+	if err != nil {
+		return Transaction{}, err
+	}
+	for _, split := range r.Splits {
+		amount, err := unmarshalAmount(split.Amount, currency)
+		if err != nil {
+			return Transaction{}, err
+		}
+		t.Splits = append(t.Splits, Split{split.Category, amount})
+	}
+	return t, nil
+}
+
+var (
+	// Overrides the layout of the encoded databases if set, indented databases are easier to read and diff.
+	indent *bool
+)
+
+// SetIndent forces indented or compact output of Marshal. Without it, databases keep
+// the layout they were read in and new databases are compact. Unmarshal reads both forms.
+func SetIndent(enabled bool) {
+	indent = &enabled
+}
+
+// isIndented checks if the encoded database spans several lines.
+func isIndented(data []byte) bool {
+	return bytes.IndexByte(bytes.TrimSpace(data), '\n') >= 0
+}
+
+// Marshal encodes the database in the on-disk format, stamped with the current version.
+func Marshal(database Database) ([]byte, error) {
+	record := databaseRecord{
+		Version:      CurrentVersion,
+		Name:         database.Name,
+		Currency:     database.Currency,
+		Transactions: make([]transactionRecord, 0, len(database.Transactions)),
+	}
+	for _, transact := range database.Transactions {
+		record.Transactions = append(record.Transactions, newBookRecord(transact, database.BookCurrency()))
+	}
+	indented := database.indented
+	if indent != nil {
+		indented = *indent
+	}
+	if indented {
+		data, err := json.MarshalIndent(record, "", "  ")
+		return append(data, '\n'), err
+	}
+	return json.Marshal(record)
+}
+
+// Unmarshal decodes a database in the on-disk format and migrates it to the current version.
+func Unmarshal(data []byte) (Database, error) {
+	var record databaseRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return Database{}, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	database := Database{
+		Version:  record.Version,
+		Name:     record.Name,
+		Currency: record.Currency,
+	}
+	if record.Transactions != nil {
+		database.Transactions = make([]Transaction, 0, len(record.Transactions))
+		for _, r := range record.Transactions {
+			transact, err := r.transaction(database.BookCurrency())
+			if err != nil {
+				return Database{}, fmt.Errorf("%w: %v", ErrCorrupt, err)
+			}
+			database.Transactions = append(database.Transactions, transact)
+		}
+	}
+	database, err := Migrate(database)
+	if err != nil {
+		return Database{}, err
+	}
+	database.indented = isIndented(data)
+	database.recompute()
+	return database, nil
+}
@@ -0,0 +1,165 @@
+package db
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBookCurrency(t *testing.T) {
+	date := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+	database := NewDatabase("test")
+	database.Currency = Dollar.Name
+	database.Store(NewTransaction("Lunch", Withdraw, 1250, date))
+	coffee := NewTransaction("Coffee", Withdraw, 250, date)
+	coffee.Currency = Euro.Name
+	database.Store(coffee)
+	data, err := Marshal(database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), `"currency":"Dollar"`); n != 1 {
+		t.Errorf("book currency written %d times, want once in the header", n)
+	}
+	decoded, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.BookCurrency().Name != Dollar.Name {
+		t.Errorf("got book currency %s, want Dollar", decoded.BookCurrency().Name)
+	}
+	for i, want := range []Currency{Dollar, Euro} {
+		if got := decoded.Transactions[i].CurrencyOf(); got.Name != want.Name {
+			t.Errorf("transaction %d: got currency %s, want %s", i, got.Name, want.Name)
+		}
+	}
+}
+
+func TestLegacyBookCurrency(t *testing.T) {
+	database, err := Unmarshal([]byte(`{"version":2,"name":"test","transaction":[{"name":"Lunch","amount":"12.50","type":"withdraw","date":"2016-03-01T00:00:00Z"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := database.Transactions[0].CurrencyOf(); got.Name != Euro.Name {
+		t.Errorf("got currency %s, want Euro", got.Name)
+	}
+}
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// goldenDatabase uses every persisted field once.
+func goldenDatabase() Database {
+	date := time.Date(2016, 3, 1, 12, 30, 0, 0, time.UTC)
+	database := NewDatabase("golden")
+	salary := NewTransaction("Salary", Deposit, 100000, date)
+	salary.Category = "Work"
+	salary.Tags = []string{"monthly"}
+	salary.Note = "March"
+	salary.FITID = "2016030100001"
+	database.Store(salary)
+	market := NewTransaction("Market", Withdraw, 3000, date.AddDate(0, 0, 1))
+	market.Splits = []Split{{"Food", 2000}, {"Household", 1000}}
+	market.TransferID = "t1"
+	database.Store(market)
+	refund := NewTransaction("Hotel", Deposit, 5000, date.AddDate(0, 0, 2))
+	refund.Currency = Dollar.Name
+	refund.Reversal = true
+	database.Store(refund)
+	return database
+}
+
+func TestIndentRoundTrip(t *testing.T) {
+	defer func() { indent = nil }()
+	database := NewDatabase("test")
+	database.Store(NewTransaction("Lunch", Withdraw, 1250, time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)))
+	compact, err := Marshal(database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetIndent(true)
+	indented, err := Marshal(database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if isIndented(compact) || !isIndented(indented) {
+		t.Fatalf("got compact %q and indented %q", compact, indented)
+	}
+	indent = nil
+	for _, data := range [][]byte{compact, indented} {
+		decoded, err := Unmarshal(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(decoded.Transactions) != 1 || decoded.Transactions[0].Name != "Lunch" || decoded.Balance() != -1250 {
+			t.Errorf("got %+v after the round trip", decoded)
+		}
+		// Writing the database back keeps its layout unless SetIndent overrides it.
+		again, err := Marshal(decoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(again) != string(data) {
+			t.Errorf("got %q, want the layout of %q", again, data)
+		}
+		SetIndent(false)
+		if again, _ := Marshal(decoded); isIndented(again) {
+			t.Errorf("SetIndent(false) kept the indented layout")
+		}
+		indent = nil
+	}
+}
+
+func TestMarshalGolden(t *testing.T) {
+	defer func() { indent = nil }()
+	SetIndent(true)
+	data, err := Marshal(goldenDatabase())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join("testdata", "database.golden.json")
+	if *update {
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	golden, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(golden) {
+		t.Errorf("the on-disk format changed, got\n%s\nwant\n%s\n(run go test -update if intended)", data, golden)
+	}
+	decoded, err := Unmarshal(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded.Transactions, goldenDatabase().Transactions) {
+		t.Errorf("got %+v after decoding the golden file", decoded.Transactions)
+	}
+}
+
+func TestNoteRoundTrip(t *testing.T) {
+	database := NewDatabase("test")
+	coffee := NewTransaction("Coffee", Withdraw, 250, time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC))
+	coffee.Note = "With Alice, \"the usual\""
+	database.Store(coffee)
+	database.Store(NewTransaction("Lunch", Withdraw, 1250, time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)))
+	data, err := Marshal(database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(data), `"note"`) != 1 {
+		t.Errorf("empty notes are written in %s", data)
+	}
+	decoded, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Transactions[0].Note != coffee.Note || decoded.Transactions[1].Note != "" {
+		t.Errorf("got notes %q and %q", decoded.Transactions[0].Note, decoded.Transactions[1].Note)
+	}
+}
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// Several transactions share the same content hash.
+	errAmbiguousHash = errors.New("ambiguous: multiple transactions share the hash")
+)
+
+// Hash returns a stable content hash of the name, amount, type and date.
+// Reversals are marked, so they do not share the hash of the corrected transaction.
+func (t Transaction) Hash() string {
+	content := fmt.Sprintf("%s\x00%d\x00%s\x00%s", t.Name, t.Amount, t.Type, t.Date.UTC().Format(time.RFC3339Nano))
+	if t.Reversal {
+		content += "\x00reversal"
+	}
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// ContainsHash checks if a transaction with the given content hash exists.
+func ContainsHash(database Database, h string) bool {
+	for _, transact := range database.Transactions {
+		if transact.Hash() == h {
+			return true
+		}
+	}
+	return false
+}
+
+// findHash returns the position of the only transaction with the given hash.
+func (db *Database) findHash(h string) (int, error) {
+	found := -1
+	for id, transact := range db.Transactions {
+		if transact.Hash() != h {
+			continue
+		}
+		if found >= 0 {
+			return -1, errAmbiguousHash
+		}
+		found = id
+	}
+	if found < 0 {
+		return -1, errTransactionNotFound
+	}
+	return found, nil
+}
+
+// ReadByHash retrieves the transaction with the given content hash.
+func (db *Database) ReadByHash(h string) (Transaction, error) {
+	ID, err := db.findHash(h)
+	if err != nil {
+		return Transaction{}, err
+	}
+	return db.Read(ID)
+}
+
+// DeleteByHash removes the transaction with the given content hash.
+func (db *Database) DeleteByHash(h string) error {
+	ID, err := db.findHash(h)
+	if err != nil {
+		return err
+	}
+	return db.Delete(ID)
+}
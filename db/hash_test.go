@@ -0,0 +1,69 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHash(t *testing.T) {
+	date := time.Date(2016, 3, 1, 12, 0, 0, 0, time.UTC)
+	coffee := NewTransaction("Coffee", Withdraw, 250, date)
+	same := NewTransaction("Coffee", Withdraw, 250, date.In(time.FixedZone("CET", 3600)))
+	same.Note, same.Category = "Cappuccino", "Food"
+	if coffee.Hash() != same.Hash() {
+		t.Errorf("got different hashes for the same content")
+	}
+	reversal := coffee
+	reversal.Reversal = true
+	for _, other := range []Transaction{
+		NewTransaction("Tea", Withdraw, 250, date),
+		NewTransaction("Coffee", Withdraw, 300, date),
+		NewTransaction("Coffee", Deposit, 250, date),
+		NewTransaction("Coffee", Withdraw, 250, date.Add(time.Second)),
+		reversal,
+	} {
+		if other.Hash() == coffee.Hash() {
+			t.Errorf("%+v shares the hash of %+v", other, coffee)
+		}
+	}
+	database := NewDatabase("test")
+	database.Store(coffee)
+	if !ContainsHash(database, same.Hash()) {
+		t.Errorf("the stored transaction is not found by its hash")
+	}
+	if ContainsHash(database, reversal.Hash()) {
+		t.Errorf("the reversal is found by the hash of the stored transaction")
+	}
+}
+
+func TestReadDeleteByHash(t *testing.T) {
+	date := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+	salary := NewTransaction("Salary", Deposit, 100000, date)
+	coffee := NewTransaction("Coffee", Withdraw, 250, date)
+	database := NewDatabase("test")
+	database.Store(salary)
+	database.Store(coffee)
+	database.Store(coffee)
+	if got, err := database.ReadByHash(salary.Hash()); err != nil || got.Name != "Salary" {
+		t.Errorf("found: got %+v, %v", got, err)
+	}
+	missing := NewTransaction("Rent", Withdraw, 50000, date).Hash()
+	if _, err := database.ReadByHash(missing); err != errTransactionNotFound {
+		t.Errorf("missing: got %v, want %v", err, errTransactionNotFound)
+	}
+	if err := database.DeleteByHash(missing); err != errTransactionNotFound {
+		t.Errorf("missing: got %v, want %v", err, errTransactionNotFound)
+	}
+	if _, err := database.ReadByHash(coffee.Hash()); err != errAmbiguousHash {
+		t.Errorf("ambiguous: got %v, want %v", err, errAmbiguousHash)
+	}
+	if err := database.DeleteByHash(coffee.Hash()); err != errAmbiguousHash {
+		t.Errorf("ambiguous: got %v, want %v", err, errAmbiguousHash)
+	}
+	if err := database.DeleteByHash(salary.Hash()); err != nil {
+		t.Fatal(err)
+	}
+	if database.Size() != 2 || ContainsHash(database, salary.Hash()) {
+		t.Errorf("got %+v after deleting the salary", database.Transactions)
+	}
+}
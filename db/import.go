@@ -0,0 +1,180 @@
+package db
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Date layout of CSV imports.
+	csvDateLayout = "2006-01-02"
+)
+
+var (
+	// The CSV file lacks a required column.
+	errMissingColumn = newClassError(ErrValidation, "invalid csv: missing column")
+)
+
+// ImportResult counts the outcome of an import.
+type ImportResult struct {
+	// Read counts all rows or records found.
+	Read int
+	// Imported counts the stored transactions.
+	Imported int
+	// Skipped counts duplicates of already stored transactions.
+	Skipped int
+	// Rejected counts invalid rows.
+	Rejected int
+}
+
+// Import stores all transactions not yet present in the database.
+// Duplicates are detected by their institution ID, or by their content hash
+// if they have none, so identical purchases with distinct IDs are all kept.
+func Import(database *Database, ts []Transaction) ImportResult {
+	result := ImportResult{Read: len(ts)}
+	for _, transact := range ts {
+		if transact.Validate() != nil {
+			result.Rejected++
+			continue
+		}
+		duplicate := database.HasFITID(transact.FITID)
+		if transact.FITID == "" {
+			duplicate = ContainsHash(*database, transact.Hash())
+		}
+		if duplicate {
+			result.Skipped++
+			continue
+		}
+		database.Store(transact)
+		result.Imported++
+	}
+	return result
+}
+
+// ImportCSV imports a CSV file in the format read by ParseCSV.
+func ImportCSV(database *Database, r io.Reader) (ImportResult, error) {
+	transactions, rejected, err := ParseCSV(r, database.BookCurrency())
+	if err != nil {
+		return ImportResult{}, err
+	}
+	result := Import(database, transactions)
+	result.Read += rejected
+	result.Rejected += rejected
+	return result, nil
+}
+
+// ParseCSV reads a CSV file with a header row naming the columns
+// name, amount and date (YYYY-MM-DD) and optionally type, category, note, currency and reversal.
+// The type takes the aliases of ParseAction; without one, negative amounts are withdrawals.
+// Amounts without a known currency are read in defaultCurrency. Malformed rows are counted as rejected.
+func ParseCSV(r io.Reader, defaultCurrency Currency) ([]Transaction, int, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, 0, err
+	}
+	columns := make(map[string]int)
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	for _, required := range []string{"name", "amount", "date"} {
+		if _, ok := columns[required]; !ok {
+			return nil, 0, fmt.Errorf("%v '%s'", errMissingColumn, required)
+		}
+	}
+	var (
+		transactions []Transaction
+		rejected     int
+	)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, 0, err
+		}
+		transact, ok := csvTransaction(record, columns, defaultCurrency)
+		if !ok {
+			rejected++
+			continue
+		}
+		transactions = append(transactions, transact)
+	}
+	return transactions, rejected, nil
+}
+
+// csvTransaction converts a CSV record, ok is false if a field is malformed.
+func csvTransaction(record []string, columns map[string]int, defaultCurrency Currency) (Transaction, bool) {
+	field := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	currency, err := LookupCurrency(field("currency"))
+	if err != nil {
+		currency = defaultCurrency
+	}
+	amount, err := parseDecimal(field("amount"), currency)
+	if err != nil {
+		return Transaction{}, false
+	}
+	date, err := time.Parse(csvDateLayout, field("date"))
+	if err != nil {
+		return Transaction{}, false
+	}
+	action := ParseAction(field("type"))
+	if action == "" && field("type") != "" {
+		return Transaction{}, false
+	} else if action == "" {
+		action = Deposit
+		if amount < ZeroValue {
+			action = Withdraw
+		}
+	}
+	transact := NewTransaction(field("name"), action, amount, date)
+	transact.Category = field("category")
+	transact.Note = field("note")
+	transact.Currency = field("currency")
+	if field("reversal") != "" {
+		transact.Reversal, err = strconv.ParseBool(field("reversal"))
+		if err != nil {
+			return Transaction{}, false
+		}
+	}
+	return transact, true
+}
+
+// ForeignCurrencies lists the distinct currencies given by name or ISO code
+// in the transactions which differ from the currency c, in order of appearance.
+func ForeignCurrencies(ts []Transaction, c Currency) []string {
+	var foreign []string
+	seen := make(map[string]bool)
+	for _, transact := range ts {
+		name := strings.ToUpper(strings.TrimSpace(transact.Currency))
+		if name == "" || seen[name] || c.Matches(name) {
+			continue
+		}
+		seen[name] = true
+		foreign = append(foreign, name)
+	}
+	return foreign
+}
+
+// AssumeCurrency sets the currency of all transactions without a known one to c.
+// Transactions naming a registered currency keep it.
+func AssumeCurrency(ts []Transaction, c Currency) {
+	for i := range ts {
+		if known, err := LookupCurrency(ts[i].Currency); err == nil && !c.Matches(ts[i].Currency) {
+			ts[i].Currency = known.Name
+			continue
+		}
+		ts[i].Currency = c.Name
+	}
+}
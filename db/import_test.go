@@ -0,0 +1,115 @@
+package db
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func importSample() []Transaction {
+	date := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+	coffee := NewTransaction("Coffee", Withdraw, 250, date)
+	coffee.FITID = "A1"
+	second := coffee
+	second.FITID = "A2"
+	salary := NewTransaction("Salary", Deposit, 100000, date)
+	return []Transaction{coffee, second, salary}
+}
+
+func TestImportTwice(t *testing.T) {
+	database := NewDatabase("test")
+	first := Import(&database, importSample())
+	if first.Imported != 3 || first.Skipped != 0 {
+		t.Fatalf("first import: got %+v, want 3 imported", first)
+	}
+	second := Import(&database, importSample())
+	if second.Imported != 0 || second.Skipped != 3 {
+		t.Fatalf("second import: got %+v, want 3 skipped", second)
+	}
+	if len(database.Transactions) != 3 {
+		t.Fatalf("got %d transactions, want 3", len(database.Transactions))
+	}
+}
+
+func TestImportKeepsDistinctFITIDs(t *testing.T) {
+	database := NewDatabase("test")
+	ts := importSample()
+	Import(&database, ts[:1])
+	result := Import(&database, ts[1:2])
+	if result.Imported != 1 {
+		t.Fatalf("identical purchase with new FITID: got %+v, want imported", result)
+	}
+}
+
+func TestAssumeCurrency(t *testing.T) {
+	ts := make([]Transaction, 4)
+	for i, currency := range []string{"", "Dollar", "XYZ", "usd"} {
+		ts[i].Currency = currency
+	}
+	AssumeCurrency(ts, Euro)
+	for i, want := range []string{"Euro", "Dollar", "Euro", "USD"} {
+		if ts[i].Currency != want {
+			t.Errorf("transaction %d: got currency %s, want %s", i, ts[i].Currency, want)
+		}
+	}
+}
+
+func TestImportCSVMixed(t *testing.T) {
+	input := strings.Join([]string{
+		"name,amount,date,type",
+		"Salary,1000.00,2016-03-01,deposit",
+		"Coffee,-2.50,2016-03-02,",
+		"Broken,abc,2016-03-02,",
+		"Undated,1.00,yesterday,",
+		"Salary,1000.00,2016-03-01,deposit",
+		",1.00,2016-03-03,",
+	}, "\n")
+	database := NewDatabase("test")
+	result, err := ImportCSV(&database, strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ImportResult{Read: 6, Imported: 2, Skipped: 1, Rejected: 3}
+	if result != want {
+		t.Errorf("got %+v, want %+v", result, want)
+	}
+	if database.Size() != 2 {
+		t.Errorf("got %d transactions, want 2", database.Size())
+	}
+}
+
+func TestParseCSVTypesAndCurrency(t *testing.T) {
+	input := strings.Join([]string{
+		"name,amount,date,type,currency",
+		"Rent,500,2016-03-01,wd,",
+		"Salary,1000,2016-03-01,dp,",
+		"Refund,3.00,2016-03-02,+,USD",
+		"Unknown,1.00,2016-03-03,sideways,",
+	}, "\n")
+	// Rows without a currency are read in the default one, which has no cents.
+	yen := Currency{Name: "CSVYen", Format: "%d", Ratio: 1, Symbol: "¥"}
+	ts, rejected, err := ParseCSV(strings.NewReader(input), yen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rejected != 1 {
+		t.Errorf("got %d rejected, want 1", rejected)
+	}
+	want := []struct {
+		action   Action
+		amount   Value
+		currency string
+	}{
+		{Withdraw, 500, ""},
+		{Deposit, 1000, ""},
+		{Deposit, 300, "USD"},
+	}
+	if len(ts) != len(want) {
+		t.Fatalf("got %d transactions, want %d", len(ts), len(want))
+	}
+	for i, w := range want {
+		if ts[i].Type != w.action || ts[i].Amount != w.amount || ts[i].Currency != w.currency {
+			t.Errorf("%s: got %s %v %q, want %s %v %q", ts[i].Name, ts[i].Type, ts[i].Amount, ts[i].Currency, w.action, w.amount, w.currency)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+var (
+	// The decimal amount has more minor digits than the currency supports.
+	errPrecisionLoss = newClassError(ErrValidation, "invalid amount: too many minor digits")
+)
+
+// decimal formats the value as a plain decimal number like "-12.50".
+func (v Value) decimal(c Currency) string {
+	sign := ""
+	if v < ZeroValue {
+		sign = "-"
+	}
+	a, ratio := magnitude(v), uint64(c.Ratio)
+	digits := c.Digits()
+	if digits == 0 {
+		return sign + strconv.FormatUint(a, 10)
+	}
+	min := strconv.FormatUint(a%ratio, 10)
+	return sign + strconv.FormatUint(a/ratio, 10) + "." + strings.Repeat("0", digits-len(min)) + min
+}
+
+// Decimal formats the value as a plain decimal number in the default currency.
+func (v Value) Decimal() string {
+	return v.decimal(DefaultCurrency)
+}
+
+// MarshalJSON encodes the value as a decimal string in the default currency.
+func (v Value) MarshalJSON() ([]byte, error) {
+	return marshalAmount(v, DefaultCurrency), nil
+}
+
+// UnmarshalJSON decodes a decimal string in the default currency or,
+// for legacy databases, an integer amount of minor units.
+func (v *Value) UnmarshalJSON(data []byte) error {
+	value, err := unmarshalAmount(data, DefaultCurrency)
+	if err != nil {
+		return err
+	}
+	*v = value
+	return nil
+}
+
+// marshalAmount encodes the value as a decimal string in the currency c.
+func marshalAmount(v Value, c Currency) json.RawMessage {
+	data, _ := json.Marshal(v.decimal(c))
+	return data
+}
+
+// unmarshalAmount decodes a decimal string in the currency c or, for legacy databases,
+// an integer amount of minor units. Digits beyond the precision of c are rejected.
+func unmarshalAmount(data []byte, c Currency) (Value, error) {
+	var minor int
+	if err := json.Unmarshal(data, &minor); err == nil {
+		return Value(minor), nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return ZeroValue, err
+	}
+	if parts := strings.SplitN(s, ".", 2); len(parts) == 2 && len(strings.TrimRight(parts[1], "0")) > c.Digits() {
+		return ZeroValue, errPrecisionLoss
+	}
+	return parseDecimal(s, c)
+}
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValueJSON(t *testing.T) {
+	for _, v := range []Value{0, 1, -1, 1250, -1250, 100000} {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded Value
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("%s: %v", data, err)
+		}
+		if decoded != v {
+			t.Errorf("%s: got %d, want %d", data, decoded, v)
+		}
+	}
+	var legacy Value
+	if err := json.Unmarshal([]byte(`-1250`), &legacy); err != nil || legacy != -1250 {
+		t.Errorf("legacy minor units: got %d, %v", legacy, err)
+	}
+	var lossy Value
+	if err := json.Unmarshal([]byte(`"12.505"`), &lossy); !errors.Is(err, errPrecisionLoss) {
+		t.Errorf("got %v, want precision loss", err)
+	}
+}
+
+func TestRecordAmountsInTransactionCurrency(t *testing.T) {
+	dinar := Currency{Name: "TestDinar", Format: "%d.%0*d", Ratio: 1000, Symbol: "D"}
+	RegisterCurrency(dinar)
+	database := NewDatabase("test")
+	transact := NewTransaction("Tea", Withdraw, 1234, time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC))
+	transact.Currency = dinar.Name
+	transact.Splits = []Split{{"Food", 1000}, {"Tip", 234}}
+	database.Store(transact)
+	data, err := Marshal(database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"amount":"1.234"`, `"amount":"1.000"`, `"amount":"0.234"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("missing %s in %s", want, data)
+		}
+	}
+	decoded, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := decoded.Transactions[0]; got.Amount != 1234 || got.Splits[1].Amount != 234 {
+		t.Errorf("got %+v, want the amounts in minor units of the dinar", got)
+	}
+}
@@ -0,0 +1,179 @@
+package db
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// JSONLinesSuffix selects the JSON-lines format, which stores the database header
+	// on the first line and one transaction per following line. Appends only add a line,
+	// deletions add a tombstone line until the database is compacted.
+	JSONLinesSuffix = ".trjl"
+	// Longest line accepted in the JSON-lines format.
+	maxLineSize = 16 * 1024 * 1024
+)
+
+var (
+	// The line is neither a transaction nor a valid tombstone.
+	errInvalidLine = newClassError(ErrCorrupt, "invalid database line")
+)
+
+// lineHeader is the first line of a JSON-lines database.
+type lineHeader struct {
+	Version  int    `json:"version"`
+	Name     string `json:"name"`
+	Currency string `json:"currency,omitempty"`
+}
+
+// lineRecord is a transaction or, if Deleted is set, a tombstone removing
+// the transaction at that position.
+type lineRecord struct {
+	Deleted *int `json:"deleted,omitempty"`
+	transactionRecord
+}
+
+// lineTombstone is the persisted shape of a deletion.
+type lineTombstone struct {
+	Deleted int `json:"deleted"`
+}
+
+// isJSONLines checks if the path uses the JSON-lines format.
+func isJSONLines(path string) bool {
+	return filepath.Ext(path) == JSONLinesSuffix
+}
+
+// openLines reads a JSON-lines database line by line, replaying tombstones.
+func openLines(path string) (Database, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Database{}, err
+	}
+	defer file.Close()
+	return readLines(file)
+}
+
+// readLinesHeader reads only the header of a JSON-lines database.
+func readLinesHeader(path string) (lineHeader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return lineHeader{}, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, maxLineSize)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return lineHeader{}, err
+		}
+		return lineHeader{}, fmt.Errorf("%w: missing header", errInvalidLine)
+	}
+	var header lineHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		return lineHeader{}, fmt.Errorf("%w 1: %v", errInvalidLine, err)
+	}
+	return header, nil
+}
+
+func readLines(r io.Reader) (Database, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLineSize)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return Database{}, err
+		}
+		return Database{}, fmt.Errorf("%w: missing header", errInvalidLine)
+	}
+	var header lineHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		return Database{}, fmt.Errorf("%w 1: %v", errInvalidLine, err)
+	}
+	database := Database{
+		Version:      header.Version,
+		Name:         header.Name,
+		Currency:     header.Currency,
+		Transactions: make([]Transaction, 0),
+	}
+	for line := 2; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var record lineRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return Database{}, fmt.Errorf("%w %d: %v", errInvalidLine, line, err)
+		}
+		if record.Deleted == nil {
+			transact, err := record.transaction(database.BookCurrency())
+			if err != nil {
+				return Database{}, fmt.Errorf("%w %d: %v", errInvalidLine, line, err)
+			}
+			database.Transactions = append(database.Transactions, transact)
+			continue
+		}
+		ID := *record.Deleted
+		if ID < 0 || ID >= len(database.Transactions) {
+			return Database{}, fmt.Errorf("%w %d: tombstone of missing transaction #%d", errInvalidLine, line, ID)
+		}
+		database.Transactions = append(database.Transactions[:ID], database.Transactions[ID+1:]...)
+	}
+	if err := scanner.Err(); err != nil {
+		return Database{}, err
+	}
+	database, err := Migrate(database)
+	if err != nil {
+		return Database{}, err
+	}
+	database.recompute()
+	return database, nil
+}
+
+// marshalLines encodes the database compacted into the JSON-lines format.
+func marshalLines(database Database) ([]byte, error) {
+	var buf bytes.Buffer
+	header, err := json.Marshal(lineHeader{Version: CurrentVersion, Name: database.Name, Currency: database.Currency})
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(header)
+	buf.WriteByte('\n')
+	for _, transact := range database.Transactions {
+		line, err := json.Marshal(newBookRecord(transact, database.BookCurrency()))
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// appendLine adds a single transaction record or tombstone to a JSON-lines database.
+func appendLine(path string, record interface{}) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(line, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Compact rewrites the existing database, dropping tombstones of the JSON-lines format.
+func Compact() error {
+	database, err := Open()
+	if err != nil {
+		return err
+	}
+	return Write(database)
+}
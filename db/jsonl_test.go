@@ -0,0 +1,70 @@
+package db
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONLines(t *testing.T) {
+	defer SetPath("")
+	path := newTestDatabase(t, JSONLinesSuffix, 0)
+	date := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, name := range []string{"Coffee", "Lunch", "Dinner"} {
+		if err := Store(NewTransaction(name, Withdraw, 1000, date)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := Delete(1); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A header, three appended transactions and a tombstone.
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 5 || lines[4] != `{"deleted":1}` {
+		t.Fatalf("got lines\n%s", data)
+	}
+	database, err := Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := transactionNames(database.Transactions); strings.Join(got, ",") != "Coffee,Dinner" || database.Balance() != -2000 {
+		t.Errorf("got %v with balance %v, want Coffee and Dinner", got, database.Balance())
+	}
+	if err := Compact(); err != nil {
+		t.Fatal(err)
+	}
+	compacted, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Count(compacted, []byte("\n")) != 3 || bytes.Contains(compacted, []byte("deleted")) {
+		t.Errorf("got compacted lines\n%s", compacted)
+	}
+	again, err := Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := transactionNames(again.Transactions); strings.Join(got, ",") != "Coffee,Dinner" {
+		t.Errorf("got %v after compacting, want Coffee and Dinner", got)
+	}
+}
+
+func TestReadLinesInvalid(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"not json\n",
+		`{"version":2,"name":"test"}` + "\n" + `{"deleted":0}` + "\n",
+		`{"version":2,"name":"test"}` + "\n" + `{"name":"Coffee","amount":"abc","type":"withdraw"}` + "\n",
+	} {
+		if _, err := readLines(strings.NewReader(in)); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("%q: got %v, want a corrupt database", in, err)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package db
+
+// Merge appends all transactions of src to dst and returns the count added.
+// With dedupe, transactions whose content hash is already present are left out.
+func Merge(dst *Database, src Database, dedupe bool) int {
+	hashes := make(map[string]bool)
+	if dedupe {
+		for _, transact := range dst.Transactions {
+			hashes[transact.Hash()] = true
+		}
+	}
+	added := 0
+	for _, transact := range src.Transactions {
+		if dedupe {
+			h := transact.Hash()
+			if hashes[h] {
+				continue
+			}
+			hashes[h] = true
+		}
+		dst.Store(transact)
+		added++
+	}
+	return added
+}
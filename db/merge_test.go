@@ -0,0 +1,40 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMerge(t *testing.T) {
+	date := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+	salary := NewTransaction("Salary", Deposit, 100000, date)
+	coffee := NewTransaction("Coffee", Withdraw, 250, date)
+	rent := NewTransaction("Rent", Withdraw, 50000, date)
+	tests := []struct {
+		dedupe bool
+		added  int
+		want   []string
+	}{
+		{false, 3, []string{"Salary", "Coffee", "Salary", "Rent", "Rent"}},
+		// Duplicates within the other database are left out as well.
+		{true, 1, []string{"Salary", "Coffee", "Rent"}},
+	}
+	for _, test := range tests {
+		dst, src := NewDatabase("dst"), NewDatabase("src")
+		dst.Store(salary)
+		dst.Store(coffee)
+		src.Store(salary)
+		src.Store(rent)
+		src.Store(rent)
+		if added := Merge(&dst, src, test.dedupe); added != test.added {
+			t.Errorf("dedupe %v: got %d added, want %d", test.dedupe, added, test.added)
+		}
+		if got := transactionNames(dst.Transactions); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("dedupe %v: got %v, want %v", test.dedupe, got, test.want)
+		}
+		if src.Size() != 3 {
+			t.Errorf("dedupe %v: the other database changed to %d transactions", test.dedupe, src.Size())
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package db
+
+import "fmt"
+
+const (
+	// CurrentVersion of the database format.
+	CurrentVersion = 2
+)
+
+// A migration upgrades a database by a single version.
+type migration func(Database) (Database, error)
+
+// Migrations indexed by the version they upgrade from.
+var migrations = map[int]migration{
+	0: migrateUnversioned,
+	1: migrateDecimalAmounts,
+}
+
+// Migrate upgrades the database to the current version.
+func Migrate(database Database) (Database, error) {
+	if database.Version > CurrentVersion {
+		return Database{}, fmt.Errorf("unsupported database version %d (newest known is %d)", database.Version, CurrentVersion)
+	}
+	for database.Version < CurrentVersion {
+		migrate, ok := migrations[database.Version]
+		if !ok {
+			return Database{}, fmt.Errorf("no migration from database version %d", database.Version)
+		}
+		next, err := migrate(database)
+		if err != nil {
+			return Database{}, err
+		}
+		next.Version = database.Version + 1
+		database = next
+	}
+	return database, nil
+}
+
+// migrateUnversioned upgrades databases written before versioning was introduced.
+// Their format is identical to version 1.
+func migrateUnversioned(database Database) (Database, error) {
+	if database.Transactions == nil {
+		database.Transactions = make([]Transaction, 0)
+	}
+	return database, nil
+}
+
+// migrateDecimalAmounts upgrades from integer to decimal string amounts.
+// Both representations are read alike, so only the version changes.
+func migrateDecimalAmounts(database Database) (Database, error) {
+	return database, nil
+}
@@ -0,0 +1,35 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMigrateUnversioned(t *testing.T) {
+	// Written before versioning, with amounts in minor units.
+	database, err := Unmarshal([]byte(`{"name":"old","transaction":[{"name":"Coffee","amount":250,"type":"withdraw","date":"2016-03-01T00:00:00Z"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if database.Version != CurrentVersion || database.Size() != 1 || database.Transactions[0].Amount != 250 || database.Balance() != -250 {
+		t.Errorf("got %+v, want an upgraded database", database)
+	}
+	data, err := Marshal(database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), fmt.Sprintf(`"version":%d`, CurrentVersion)) || !strings.Contains(string(data), `"amount":"2.50"`) {
+		t.Errorf("got %s, want the current version and decimal amounts", data)
+	}
+	empty, err := Unmarshal([]byte(`{"name":"empty"}`))
+	if err != nil || empty.Transactions == nil {
+		t.Errorf("got %+v, %v, want an empty transaction list", empty, err)
+	}
+}
+
+func TestMigrateNewerVersion(t *testing.T) {
+	if _, err := Migrate(Database{Version: CurrentVersion + 1}); err == nil {
+		t.Error("got no error for a database from the future")
+	}
+}
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	// OFX date layouts, the time part is optional.
+	ofxDateLayout     = "20060102"
+	ofxDateTimeLayout = "20060102150405"
+)
+
+var (
+	// The OFX statement could not be parsed.
+	errInvalidOFX = newClassError(ErrValidation, "invalid ofx: malformed statement transaction")
+)
+
+// ParseOFX reads all <STMTTRN> records from an OFX statement.
+// Both the SGML (OFX 1.x) and the XML (OFX 2.x) flavours are supported.
+// The currency of the transactions is the ISO code of the statement.
+func ParseOFX(r io.Reader) ([]Transaction, error) {
+	var (
+		transactions []Transaction
+		fields       map[string]string
+		// The default currency of the statement.
+		currency string
+	)
+	scanner := bufio.NewScanner(r)
+	scanner.Split(scanOFXTags)
+	for scanner.Scan() {
+		tag, value := splitOFXTag(scanner.Text())
+		switch {
+		case tag == "STMTTRN":
+			fields = make(map[string]string)
+		case tag == "/STMTTRN":
+			if fields == nil {
+				return nil, errInvalidOFX
+			}
+			transact, err := ofxTransaction(fields)
+			if err != nil {
+				return nil, err
+			}
+			transactions = append(transactions, transact)
+			fields = nil
+		case fields != nil && !strings.HasPrefix(tag, "/"):
+			fields[tag] = value
+		case tag == "CURDEF":
+			currency = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for i := range transactions {
+		if transactions[i].Currency == "" {
+			transactions[i].Currency = currency
+		}
+	}
+	return transactions, nil
+}
+
+// scanOFXTags splits the input at every opening angle bracket.
+func scanOFXTags(data []byte, atEOF bool) (int, []byte, error) {
+	start := bytes.IndexByte(data, '<')
+	if start < 0 {
+		if atEOF {
+			return len(data), nil, nil
+		}
+		return 0, nil, nil
+	}
+	end := bytes.IndexByte(data[start+1:], '<')
+	if end < 0 {
+		if atEOF {
+			return len(data), data[start+1:], nil
+		}
+		return start, nil, nil
+	}
+	return start + 1 + end, data[start+1 : start+1+end], nil
+}
+
+// splitOFXTag separates a token like "TRNAMT>-12.50" into tag and value.
+func splitOFXTag(token string) (string, string) {
+	parts := strings.SplitN(token, ">", 2)
+	tag := strings.ToUpper(strings.TrimSpace(parts[0]))
+	if len(parts) < 2 {
+		return tag, ""
+	}
+	return tag, strings.TrimSpace(parts[1])
+}
+
+func ofxTransaction(fields map[string]string) (Transaction, error) {
+	amount, err := parseDecimal(fields["TRNAMT"], DefaultCurrency)
+	if err != nil {
+		return Transaction{}, errInvalidOFX
+	}
+	date, err := parseOFXDate(fields["DTPOSTED"])
+	if err != nil {
+		return Transaction{}, err
+	}
+	name := fields["NAME"]
+	if name == "" {
+		name = fields["MEMO"]
+	}
+	action := Deposit
+	if amount < ZeroValue {
+		action = Withdraw
+	}
+	transact := NewTransaction(name, action, amount, date)
+	transact.FITID = fields["FITID"]
+	transact.Currency = fields["CURSYM"]
+	return transact, nil
+}
+
+// parseOFXDate reads the leading date (and time) of an OFX timestamp,
+// ignoring fractional seconds and timezone information.
+func parseOFXDate(s string) (time.Time, error) {
+	if len(s) >= len(ofxDateTimeLayout) {
+		return time.Parse(ofxDateTimeLayout, s[:len(ofxDateTimeLayout)])
+	}
+	if len(s) >= len(ofxDateLayout) {
+		return time.Parse(ofxDateLayout, s[:len(ofxDateLayout)])
+	}
+	return time.Time{}, errInvalidOFX
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseOFX(t *testing.T) {
+	file, err := os.Open("testdata/statement.ofx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	ts, err := ParseOFX(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		name   string
+		action Action
+		amount Value
+		date   time.Time
+		fitid  string
+	}{
+		{"Salary", Deposit, 100000, time.Date(2016, 3, 1, 12, 0, 0, 0, time.UTC), "2016030100001"},
+		{"Coffee Shop", Withdraw, 1250, time.Date(2016, 3, 2, 0, 0, 0, 0, time.UTC), "2016030200002"},
+	}
+	if len(ts) != len(want) {
+		t.Fatalf("got %d transactions, want %d", len(ts), len(want))
+	}
+	for i, w := range want {
+		got := ts[i]
+		if got.Name != w.name || got.Type != w.action || got.Amount != w.amount || !got.Date.Equal(w.date) || got.FITID != w.fitid || got.Currency != "EUR" {
+			t.Errorf("transaction %d: got %+v, want %+v in EUR", i, got, w)
+		}
+	}
+}
+
+func TestParseOFXXML(t *testing.T) {
+	ts, err := ParseOFX(strings.NewReader(`<OFX><STMTTRN><DTPOSTED>20160302</DTPOSTED><TRNAMT>-3.00</TRNAMT><FITID>X</FITID><NAME>Bus</NAME></STMTTRN></OFX>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ts) != 1 || ts[0].Name != "Bus" || ts[0].Type != Withdraw || ts[0].Amount != 300 {
+		t.Errorf("got %+v, want a withdrawal of 3.00 for Bus", ts)
+	}
+}
+
+func TestParseOFXInvalid(t *testing.T) {
+	for _, in := range []string{
+		`<STMTTRN><DTPOSTED>20160302<TRNAMT>abc<NAME>Bus</STMTTRN>`,
+		`<STMTTRN><DTPOSTED>yesterday<TRNAMT>1.00<NAME>Bus</STMTTRN>`,
+		`</STMTTRN>`,
+	} {
+		if _, err := ParseOFX(strings.NewReader(in)); err == nil {
+			t.Errorf("%s: got no error", in)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package db
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+const (
+	// QIF header of bank account transactions.
+	qifBankHeader = "!Type:Bank"
+	// QIF date layout (month/day/year).
+	qifDateLayout = "01/02/2006"
+)
+
+// ExportQIF writes all transactions in the Quicken Interchange Format.
+// Amounts are signed by their type, withdrawals are negative.
+func ExportQIF(w io.Writer, database Database) error {
+	buf := bufio.NewWriter(w)
+	fmt.Fprintln(buf, qifBankHeader)
+	for _, transact := range database.Transactions {
+		fmt.Fprintf(buf, "D%s\n", transact.Date.Format(qifDateLayout))
+		fmt.Fprintf(buf, "T%s\n", transact.Signed().decimal(transact.CurrencyOf()))
+		fmt.Fprintf(buf, "P%s\n", transact.Name)
+		if transact.Note != "" {
+			fmt.Fprintf(buf, "M%s\n", transact.Note)
+		}
+		if transact.Category != "" {
+			fmt.Fprintf(buf, "L%s\n", transact.Category)
+		}
+		fmt.Fprintln(buf, "^")
+	}
+	return buf.Flush()
+}
@@ -0,0 +1,28 @@
+package db
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestExportQIFGolden(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportQIF(&buf, goldenDatabase()); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join("testdata", "export.golden.qif")
+	if *update {
+		if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	golden, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != string(golden) {
+		t.Errorf("got\n%s\nwant\n%s\n(run go test -update if intended)", buf.String(), golden)
+	}
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"sort"
+	"time"
+)
+
+var (
+	// ReconcileWindow is the largest date difference of matching transactions.
+	ReconcileWindow = 3 * 24 * time.Hour
+)
+
+// Reconcile compares the book against a bank statement. Transactions match if their
+// signed amounts are equal and their dates are at most ReconcileWindow apart, each
+// transaction matches at most once and the closest dates are paired first. Book
+// transactions outside the period of the statement are ignored.
+func Reconcile(book Database, stmt []Transaction) (missingInBook, missingInStmt []Transaction) {
+	if len(stmt) == 0 {
+		return nil, nil
+	}
+	first, last := stmt[0].Date, stmt[0].Date
+	for _, transact := range stmt {
+		if transact.Date.Before(first) {
+			first = transact.Date
+		}
+		if transact.Date.After(last) {
+			last = transact.Date
+		}
+	}
+	first, last = first.Add(-ReconcileWindow), last.Add(ReconcileWindow)
+	var entries []Transaction
+	for _, transact := range book.Transactions {
+		if !transact.Date.Before(first) && !transact.Date.After(last) {
+			entries = append(entries, transact)
+		}
+	}
+	type pair struct {
+		entry, line int
+		distance    time.Duration
+	}
+	var pairs []pair
+	for i, entry := range entries {
+		for j, line := range stmt {
+			if entry.Signed() != line.Signed() {
+				continue
+			}
+			distance := entry.Date.Sub(line.Date)
+			if distance < 0 {
+				distance = -distance
+			}
+			if distance <= ReconcileWindow {
+				pairs = append(pairs, pair{i, j, distance})
+			}
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i].distance < pairs[j].distance
+	})
+	entryMatched, lineMatched := make([]bool, len(entries)), make([]bool, len(stmt))
+	for _, p := range pairs {
+		if !entryMatched[p.entry] && !lineMatched[p.line] {
+			entryMatched[p.entry], lineMatched[p.line] = true, true
+		}
+	}
+	for j, line := range stmt {
+		if !lineMatched[j] {
+			missingInBook = append(missingInBook, line)
+		}
+	}
+	for i, entry := range entries {
+		if !entryMatched[i] {
+			missingInStmt = append(missingInStmt, entry)
+		}
+	}
+	return missingInBook, missingInStmt
+}
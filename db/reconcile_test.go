@@ -0,0 +1,51 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReconcile(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2016, 3, d, 0, 0, 0, 0, time.UTC) }
+	book := NewDatabase("test")
+	for _, transact := range []Transaction{
+		NewTransaction("Salary", Deposit, 100000, day(1)),
+		NewTransaction("Coffee", Withdraw, 250, day(5)),
+		NewTransaction("Rent", Withdraw, 50000, day(10)),
+		NewTransaction("Lunch", Withdraw, 1250, day(12)),
+		// Before the statement period, so it is ignored.
+		NewTransaction("Old", Withdraw, 999, time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)),
+	} {
+		book.Store(transact)
+	}
+	stmt := []Transaction{
+		// Exact match.
+		NewTransaction("SALARY ACME", Deposit, 100000, day(1)),
+		// Matches within the window.
+		NewTransaction("CAFE", Withdraw, 250, day(7)),
+		// Same day but the amount is off by a cent.
+		NewTransaction("RENT", Withdraw, 50001, day(10)),
+		// Same amount but outside the window.
+		NewTransaction("LUNCH", Withdraw, 1250, day(16)),
+	}
+	missingInBook, missingInStmt := Reconcile(book, stmt)
+	if got, want := transactionNames(missingInBook), []string{"RENT", "LUNCH"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("missing in book: got %v, want %v", got, want)
+	}
+	if got, want := transactionNames(missingInStmt), []string{"Rent", "Lunch"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("missing in statement: got %v, want %v", got, want)
+	}
+}
+
+func TestReconcileClosestFirst(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2016, 3, d, 0, 0, 0, 0, time.UTC) }
+	book := NewDatabase("test")
+	book.Store(NewTransaction("First", Withdraw, 250, day(4)))
+	book.Store(NewTransaction("Second", Withdraw, 250, day(6)))
+	stmt := []Transaction{NewTransaction("CAFE", Withdraw, 250, day(6))}
+	missingInBook, missingInStmt := Reconcile(book, stmt)
+	if len(missingInBook) != 0 || !reflect.DeepEqual(transactionNames(missingInStmt), []string{"First"}) {
+		t.Errorf("got %v missing in book and %v in statement, want only First unmatched", transactionNames(missingInBook), transactionNames(missingInStmt))
+	}
+}
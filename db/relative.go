@@ -0,0 +1,67 @@
+package db
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// The relative date is not a count followed by d, w, m or y.
+	errInvalidRelativeDate = newClassError(ErrValidation, "invalid relative date")
+)
+
+// ParseRelativeDate resolves durations like 30d, 2w, 1m or 1y into the start of the day
+// that long before now.
+func ParseRelativeDate(s string, now time.Time) (time.Time, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) < 2 {
+		return time.Time{}, fmt.Errorf("%w '%s'", errInvalidRelativeDate, s)
+	}
+	count, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || count < 0 {
+		return time.Time{}, fmt.Errorf("%w '%s'", errInvalidRelativeDate, s)
+	}
+	var years, months, days int
+	switch s[len(s)-1] {
+	case 'd':
+		days = count
+	case 'w':
+		days = 7 * count
+	case 'm':
+		months = count
+	case 'y':
+		years = count
+	default:
+		return time.Time{}, fmt.Errorf("%w '%s'", errInvalidRelativeDate, s)
+	}
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return day.AddDate(-years, -months, -days), nil
+}
+
+// DayOf returns the start of the day containing t and the start of the next day.
+func DayOf(t time.Time) (from, to time.Time) {
+	from = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return from, from.AddDate(0, 0, 1)
+}
+
+// WeekOf returns the start of the ISO 8601 week containing t, which begins on Monday,
+// and the start of the next week.
+func WeekOf(t time.Time) (from, to time.Time) {
+	day, _ := DayOf(t)
+	from = day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	return from, from.AddDate(0, 0, 7)
+}
+
+// MonthOf returns the start of the calendar month containing t and the start of the next month.
+func MonthOf(t time.Time) (from, to time.Time) {
+	from = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	return from, from.AddDate(0, 1, 0)
+}
+
+// WallClock returns the date and time of day of t as read in its own location, placed in UTC.
+// Parsed dates are UTC while the clock is local, comparing wall clocks keeps both on one calendar.
+func WallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPeriodBoundaries(t *testing.T) {
+	date := func(y int, m time.Month, d, h, min int) time.Time { return time.Date(y, m, d, h, min, 0, 0, time.UTC) }
+	tests := []struct {
+		name     string
+		bounds   func(time.Time) (time.Time, time.Time)
+		now      time.Time
+		from, to time.Time
+	}{
+		{"day at midnight", DayOf, date(2026, 10, 16, 0, 0), date(2026, 10, 16, 0, 0), date(2026, 10, 17, 0, 0)},
+		{"day before midnight", DayOf, date(2026, 10, 16, 23, 59), date(2026, 10, 16, 0, 0), date(2026, 10, 17, 0, 0)},
+		{"week on sunday", WeekOf, date(2026, 10, 18, 23, 59), date(2026, 10, 12, 0, 0), date(2026, 10, 19, 0, 0)},
+		{"week on monday", WeekOf, date(2026, 10, 19, 0, 0), date(2026, 10, 19, 0, 0), date(2026, 10, 26, 0, 0)},
+		{"week across years", WeekOf, date(2027, 1, 1, 12, 0), date(2026, 12, 28, 0, 0), date(2027, 1, 4, 0, 0)},
+		{"month end", MonthOf, date(2026, 12, 31, 23, 59), date(2026, 12, 1, 0, 0), date(2027, 1, 1, 0, 0)},
+	}
+	for _, test := range tests {
+		from, to := test.bounds(test.now)
+		if !from.Equal(test.from) || !to.Equal(test.to) {
+			t.Errorf("%s: got %v - %v, want %v - %v", test.name, from, to, test.from, test.to)
+		}
+	}
+}
+
+func TestFilterLocalBounds(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	// Late evening in New York is already the next day in UTC.
+	now := time.Date(2026, 10, 16, 22, 0, 0, 0, newYork)
+	today := NewTransaction("Today", Withdraw, 100, time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC))
+	tomorrow := NewTransaction("Tomorrow", Withdraw, 100, time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC))
+	var opts FilterOptions
+	opts.From, opts.To = DayOf(now)
+	if !opts.Match(today) {
+		t.Error("transaction of today not matched")
+	}
+	if opts.Match(tomorrow) {
+		t.Error("transaction of tomorrow matched")
+	}
+}
+
+func TestParseRelativeDate(t *testing.T) {
+	now := time.Date(2016, 3, 15, 15, 30, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+		ok   bool
+	}{
+		{"0d", time.Date(2016, 3, 15, 0, 0, 0, 0, time.UTC), true},
+		{"30d", time.Date(2016, 2, 14, 0, 0, 0, 0, time.UTC), true},
+		{"2w", time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC), true},
+		{"1m", time.Date(2016, 2, 15, 0, 0, 0, 0, time.UTC), true},
+		{"1Y", time.Date(2015, 3, 15, 0, 0, 0, 0, time.UTC), true},
+		{"d", time.Time{}, false},
+		{"-1d", time.Time{}, false},
+		{"3h", time.Time{}, false},
+		{"", time.Time{}, false},
+	}
+	for _, test := range tests {
+		got, err := ParseRelativeDate(test.in, now)
+		if (err == nil) != test.ok || !got.Equal(test.want) {
+			t.Errorf("%q: got %v, %v, want %v", test.in, got, err, test.want)
+		}
+		if err != nil && !errors.Is(err, ErrValidation) {
+			t.Errorf("%q: got error %v, want a validation error", test.in, err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package db
+
+import "math"
+
+// RoundingMode decides what happens to minor digits beyond the precision of a currency.
+type RoundingMode int
+
+const (
+	// RoundHalfUp rounds halves away from zero, e.g. 12.995 becomes 13.00.
+	RoundHalfUp RoundingMode = iota
+	// RoundHalfEven rounds halves to the nearest even digit, e.g. 12.985 becomes 12.98.
+	RoundHalfEven
+	// RoundTruncate drops the extra digits, e.g. 12.999 becomes 12.99.
+	RoundTruncate
+)
+
+var (
+	// The rounding applied by Parse and Convert.
+	rounding = RoundHalfUp
+)
+
+// SetRounding changes the rounding applied by Parse and Convert.
+func SetRounding(mode RoundingMode) {
+	rounding = mode
+}
+
+// roundFloat rounds a number of minor units according to the rounding mode.
+func (m RoundingMode) roundFloat(x float64) float64 {
+	switch m {
+	case RoundTruncate:
+		return math.Trunc(x)
+	case RoundHalfEven:
+		return math.RoundToEven(x)
+	default:
+		return math.Round(x)
+	}
+}
+
+// roundUp decides if the magnitude ending in last has to be increased
+// by one because of the dropped digits in rest.
+func (m RoundingMode) roundUp(last Value, rest string) bool {
+	if rest == "" || m == RoundTruncate {
+		return false
+	}
+	switch {
+	case rest[0] > '5':
+		return true
+	case rest[0] < '5':
+		return false
+	case m == RoundHalfUp:
+		return true
+	}
+	for _, r := range rest[1:] {
+		if r != '0' {
+			return true
+		}
+	}
+	return last%2 == 1
+}
@@ -0,0 +1,33 @@
+package db
+
+import "testing"
+
+func TestRoundingModes(t *testing.T) {
+	defer SetRounding(rounding)
+	SetRate(Dollar.Name, Euro.Name, 0.5)
+	defer delete(rates, rateKey(Dollar.Name, Euro.Name))
+	tests := []struct {
+		mode RoundingMode
+		// Parsed values of 12.996, 12.995, 12.985 and -12.996.
+		parsed [4]Value
+		// Conversions of 0.01$ and 0.03$ at half the rate.
+		converted [2]Value
+	}{
+		{RoundHalfUp, [4]Value{1300, 1300, 1299, -1300}, [2]Value{1, 2}},
+		{RoundHalfEven, [4]Value{1300, 1300, 1298, -1300}, [2]Value{0, 2}},
+		{RoundTruncate, [4]Value{1299, 1299, 1298, -1299}, [2]Value{0, 1}},
+	}
+	for _, test := range tests {
+		SetRounding(test.mode)
+		for i, in := range []string{"12.996", "12.995", "12.985", "-12.996"} {
+			if got := Parse(in); got != test.parsed[i] {
+				t.Errorf("mode %d: Parse(%q) got %v, want %v", test.mode, in, got, test.parsed[i])
+			}
+		}
+		for i, in := range []Value{1, 3} {
+			if got, ok := Convert(in, Dollar, Euro); !ok || got != test.converted[i] {
+				t.Errorf("mode %d: Convert(%d) got %d, want %d", test.mode, in, got, test.converted[i])
+			}
+		}
+	}
+}
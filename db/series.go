@@ -0,0 +1,38 @@
+package db
+
+import (
+	"sort"
+	"time"
+)
+
+// DayBalance is the balance at the end of a day.
+type DayBalance struct {
+	Date    time.Time `json:"date"`
+	Balance Value     `json:"balance"`
+}
+
+// BalanceSeries computes the running balance at the end of each day between from and to
+// (inclusive). Transactions before from count towards the opening balance, days
+// without transactions carry the previous balance forward.
+func BalanceSeries(ts []Transaction, from, to time.Time) []DayBalance {
+	sorted := make([]Transaction, len(ts))
+	copy(sorted, ts)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+	var (
+		series  []DayBalance
+		balance Value
+		next    int
+	)
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	for !day.After(to) {
+		end := day.AddDate(0, 0, 1)
+		for ; next < len(sorted) && sorted[next].Date.Before(end); next++ {
+			balance = balance.Add(sorted[next].Signed())
+		}
+		series = append(series, DayBalance{Date: day, Balance: balance})
+		day = end
+	}
+	return series
+}
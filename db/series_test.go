@@ -0,0 +1,29 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBalanceSeries(t *testing.T) {
+	day := func(d, h int) time.Time { return time.Date(2016, 3, d, h, 0, 0, 0, time.UTC) }
+	ts := []Transaction{
+		NewTransaction("Rent", Withdraw, 50000, day(3, 18)),
+		NewTransaction("Salary", Deposit, 100000, day(1, 9)),
+		NewTransaction("Coffee", Withdraw, 250, day(3, 8)),
+		NewTransaction("Savings", Deposit, 10000, day(6, 0)),
+	}
+	series := BalanceSeries(ts, day(2, 12), day(5, 0))
+	want := []Value{100000, 49750, 49750, 49750}
+	if len(series) != len(want) {
+		t.Fatalf("got %+v, want %d days", series, len(want))
+	}
+	for i, balance := range want {
+		if !series[i].Date.Equal(day(2+i, 0)) || series[i].Balance != balance {
+			t.Errorf("day %d: got %v on %v, want %v on %v", i, series[i].Balance, series[i].Date, balance, day(2+i, 0))
+		}
+	}
+	if empty := BalanceSeries(ts, day(5, 0), day(4, 0)); len(empty) != 0 {
+		t.Errorf("got %+v for an empty range", empty)
+	}
+}
@@ -0,0 +1,59 @@
+package db
+
+import "fmt"
+
+var (
+	// The splits of a transaction do not add up to its amount.
+	errSplitMismatch = newClassError(ErrValidation, "invalid transaction: splits do not sum to the amount")
+)
+
+// Split allocates a part of the transaction amount to a category.
+type Split struct {
+	Category string `json:"category"`
+	Amount   Value  `json:"amount"`
+}
+
+// splitProblems lists the invalid splits and a mismatch with the amount.
+func (t Transaction) splitProblems() []error {
+	if len(t.Splits) == 0 {
+		return nil
+	}
+	var (
+		problems []error
+		sum      Value
+	)
+	for _, split := range t.Splits {
+		if split.Amount < ZeroValue {
+			problems = append(problems, fmt.Errorf("%w of split '%s'", errNegativeAmount, split.Category))
+		}
+		sum = sum.Add(split.Amount)
+	}
+	if sum != t.Amount {
+		problems = append(problems, fmt.Errorf("%w (%s of %s)", errSplitMismatch, sum.Format(t.CurrencyOf()), t.Amount.Format(t.CurrencyOf())))
+	}
+	return problems
+}
+
+// Parts returns a transaction per split with the category and amount of the split,
+// or the transaction itself if it is not split.
+func (t Transaction) Parts() []Transaction {
+	if len(t.Splits) == 0 {
+		return []Transaction{t}
+	}
+	parts := make([]Transaction, len(t.Splits))
+	for i, split := range t.Splits {
+		parts[i] = t
+		parts[i].Category, parts[i].Amount, parts[i].Splits = split.Category, split.Amount, nil
+	}
+	return parts
+}
+
+// SplitParts replaces split transactions by their parts, so amounts can be attributed
+// to the categories of the splits.
+func SplitParts(ts []Transaction) []Transaction {
+	parts := make([]Transaction, 0, len(ts))
+	for _, transact := range ts {
+		parts = append(parts, transact.Parts()...)
+	}
+	return parts
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSplitValidation(t *testing.T) {
+	receipt := NewTransaction("Market", Withdraw, 3000, time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC))
+	tests := []struct {
+		name   string
+		splits []Split
+		want   []error
+	}{
+		{"unsplit", nil, nil},
+		{"sum", []Split{{"Food", 2000}, {"Household", 1000}}, nil},
+		{"short", []Split{{"Food", 2000}}, []error{errSplitMismatch}},
+		{"negative", []Split{{"Food", 4000}, {"Refund", -1000}}, []error{errNegativeAmount}},
+	}
+	for _, test := range tests {
+		receipt.Splits = test.splits
+		problems := receipt.splitProblems()
+		if len(problems) != len(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, problems, test.want)
+			continue
+		}
+		for i := range problems {
+			if !errors.Is(problems[i], test.want[i]) {
+				t.Errorf("%s: got %v, want %v", test.name, problems[i], test.want[i])
+			}
+		}
+		if err := receipt.Validate(); (err != nil) != (len(test.want) > 0) {
+			t.Errorf("%s: got Validate() = %v", test.name, err)
+		}
+	}
+}
+
+func TestSplitParts(t *testing.T) {
+	receipt := NewTransaction("Market", Withdraw, 3000, time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC))
+	receipt.Category = "Food"
+	receipt.Splits = []Split{{"Food", 2000}, {"Household", 1000}}
+	coffee := NewTransaction("Coffee", Withdraw, 250, receipt.Date)
+	parts := SplitParts([]Transaction{receipt, coffee})
+	if len(parts) != 3 {
+		t.Fatalf("got %d parts, want 3", len(parts))
+	}
+	for i, want := range []Split{{"Food", 2000}, {"Household", 1000}, {"", 250}} {
+		if parts[i].Category != want.Category || parts[i].Amount != want.Amount || parts[i].Splits != nil {
+			t.Errorf("part %d: got %+v, want %+v", i, parts[i], want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package db
+
+import "sort"
+
+// NameStat counts the occurrences and net total of a name or category.
+type NameStat struct {
+	Count int
+	Total Value
+}
+
+func distinct(ts []Transaction, key func(Transaction) string) map[string]NameStat {
+	stats := make(map[string]NameStat)
+	for _, transact := range ts {
+		stat := stats[key(transact)]
+		stat.Count++
+		stat.Total = stat.Total.Add(transact.Signed())
+		stats[key(transact)] = stat
+	}
+	return stats
+}
+
+// DistinctNames collects the stats of every distinct transaction name.
+func DistinctNames(ts []Transaction) map[string]NameStat {
+	return distinct(ts, func(t Transaction) string { return t.Name })
+}
+
+// DistinctCategories collects the stats of every distinct transaction category.
+// Splits count towards their categories.
+func DistinctCategories(ts []Transaction) map[string]NameStat {
+	return distinct(SplitParts(ts), func(t Transaction) string { return t.Category })
+}
+
+// Totals sums up the deposits and withdrawals of the transactions.
+func Totals(ts []Transaction) (deposits, withdrawals, net Value) {
+	for _, transact := range ts {
+		switch transact.Type {
+		case Deposit:
+			deposits = deposits.Add(transact.Booked())
+		case Withdraw:
+			withdrawals = withdrawals.Add(transact.Booked())
+		}
+	}
+	return deposits, withdrawals, deposits.Sub(withdrawals)
+}
+
+// Count returns the number of transactions matching the predicate.
+// A nil predicate matches every transaction.
+func Count(ts []Transaction, pred func(Transaction) bool) int {
+	count := 0
+	for _, transact := range ts {
+		if pred == nil || pred(transact) {
+			count++
+		}
+	}
+	return count
+}
+
+// GroupBy sums up the net amount of the transactions per key.
+func GroupBy(ts []Transaction, keyFn func(Transaction) string) map[string]Value {
+	groups := make(map[string]Value)
+	for _, transact := range ts {
+		key := keyFn(transact)
+		groups[key] = groups[key].Add(transact.Signed())
+	}
+	return groups
+}
+
+// Oldest returns the position and the transaction with the earliest date,
+// the first stored one on ties. ok is false if there are no transactions.
+func Oldest(ts []Transaction) (id int, oldest Transaction, ok bool) {
+	if len(ts) == 0 {
+		return -1, Transaction{}, false
+	}
+	for i, transact := range ts {
+		if transact.Date.Before(ts[id].Date) {
+			id = i
+		}
+	}
+	return id, ts[id], true
+}
+
+// Latest returns the position and the transaction with the most recent date,
+// the last stored one on ties. ok is false if there are no transactions.
+func Latest(ts []Transaction) (id int, latest Transaction, ok bool) {
+	if len(ts) == 0 {
+		return -1, Transaction{}, false
+	}
+	for i, transact := range ts {
+		if !transact.Date.Before(ts[id].Date) {
+			id = i
+		}
+	}
+	return id, ts[id], true
+}
+
+// Shares computes the percentage of each part of the total in tenths of a percent.
+// The shares are rounded by the largest remainder, so they always sum up to 1000
+// unless all parts are zero.
+func Shares(parts []Value) []int {
+	var total Value
+	for _, part := range parts {
+		total = total.Add(abs(part))
+	}
+	shares := make([]int, len(parts))
+	if total == ZeroValue {
+		return shares
+	}
+	remainders := make([]int, len(parts))
+	left := 1000
+	for i, part := range parts {
+		scaled := int64(abs(part)) * 1000
+		shares[i] = int(scaled / int64(total))
+		remainders[i] = i
+		left -= shares[i]
+	}
+	sort.SliceStable(remainders, func(a, b int) bool {
+		x, y := int64(abs(parts[remainders[a]]))*1000%int64(total), int64(abs(parts[remainders[b]]))*1000%int64(total)
+		return x > y
+	})
+	for i := 0; i < left; i++ {
+		shares[remainders[i]]++
+	}
+	return shares
+}
@@ -0,0 +1,111 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOldestLatest(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2016, 3, d, 0, 0, 0, 0, time.UTC) }
+	ts := []Transaction{
+		NewTransaction("B", Withdraw, 100, day(2)),
+		NewTransaction("A", Withdraw, 100, day(1)),
+		NewTransaction("A2", Withdraw, 100, day(1)),
+		NewTransaction("C", Withdraw, 100, day(3)),
+		NewTransaction("C2", Withdraw, 100, day(3)),
+	}
+	if id, oldest, ok := Oldest(ts); !ok || id != 1 || oldest.Name != "A" {
+		t.Errorf("oldest: got #%d %s, want #1 A", id, oldest.Name)
+	}
+	if id, latest, ok := Latest(ts); !ok || id != 4 || latest.Name != "C2" {
+		t.Errorf("latest: got #%d %s, want #4 C2", id, latest.Name)
+	}
+	if _, _, ok := Oldest(nil); ok {
+		t.Error("oldest of no transactions found")
+	}
+}
+
+func TestDistinctCategoriesSplits(t *testing.T) {
+	date := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+	receipt := NewTransaction("Market", Withdraw, 3000, date)
+	receipt.Category = "Food"
+	receipt.Splits = []Split{{"Food", 2000}, {"Household", 1000}}
+	rent := NewTransaction("Rent", Withdraw, 50000, date)
+	rent.Category = "Home"
+	stats := DistinctCategories([]Transaction{receipt, rent})
+	want := map[string]NameStat{
+		"Food":      {1, -2000},
+		"Household": {1, -1000},
+		"Home":      {1, -50000},
+	}
+	if len(stats) != len(want) {
+		t.Errorf("got %v, want %v", stats, want)
+	}
+	for category, stat := range want {
+		if stats[category] != stat {
+			t.Errorf("%s: got %+v, want %+v", category, stats[category], stat)
+		}
+	}
+}
+
+func TestDistinctNames(t *testing.T) {
+	date := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+	stats := DistinctNames([]Transaction{
+		NewTransaction("Coffee", Withdraw, 250, date),
+		NewTransaction("coffee", Withdraw, 300, date),
+		NewTransaction("Coffee", Withdraw, 250, date),
+		NewTransaction("Salary", Deposit, 100000, date),
+	})
+	want := map[string]NameStat{
+		"Coffee": {2, -500},
+		"coffee": {1, -300},
+		"Salary": {1, 100000},
+	}
+	if len(stats) != len(want) {
+		t.Errorf("got %v, want %v", stats, want)
+	}
+	for name, stat := range want {
+		if stats[name] != stat {
+			t.Errorf("%s: got %+v, want %+v", name, stats[name], stat)
+		}
+	}
+}
+
+func TestTotals(t *testing.T) {
+	date := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+	refund := NewTransaction("Refund", Withdraw, 1000, date)
+	refund.Reversal = true
+	deposits, withdrawals, net := Totals([]Transaction{
+		NewTransaction("Salary", Deposit, 100000, date),
+		NewTransaction("Rent", Withdraw, 50000, date),
+		NewTransaction("Coffee", Withdraw, 250, date),
+		refund,
+	})
+	if deposits != 100000 || withdrawals != 49250 || net != 50750 {
+		t.Errorf("got %v, %v, %v, want 1000.00, 492.50, 507.50", deposits, withdrawals, net)
+	}
+	if deposits, withdrawals, net := Totals(nil); deposits != 0 || withdrawals != 0 || net != 0 {
+		t.Errorf("got %v, %v, %v for no transactions", deposits, withdrawals, net)
+	}
+}
+
+func TestShares(t *testing.T) {
+	tests := []struct {
+		parts []Value
+		want  []int
+	}{
+		{[]Value{100, 100, 100}, []int{334, 333, 333}},
+		{[]Value{-500, 250, 250}, []int{500, 250, 250}},
+		{[]Value{1, 1, 1, 1, 1, 1, 1}, []int{143, 143, 143, 143, 143, 143, 142}},
+		{[]Value{2, 997, 1}, []int{2, 997, 1}},
+		{[]Value{0, 0}, []int{0, 0}},
+		{nil, []int{}},
+	}
+	for _, test := range tests {
+		got := Shares(test.parts)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Shares(%v): got %v, want %v", test.parts, got, test.want)
+		}
+	}
+}
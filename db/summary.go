@@ -0,0 +1,113 @@
+package db
+
+import (
+	"sort"
+	"time"
+)
+
+// Summary of all transactions within a period.
+type Summary struct {
+	Count                      int
+	Deposits, Withdrawals, Net Value
+}
+
+func (s *Summary) add(transact Transaction) {
+	s.Count++
+	switch transact.Type {
+	case Deposit:
+		s.Deposits = s.Deposits.Add(transact.Booked())
+	case Withdraw:
+		s.Withdrawals = s.Withdrawals.Add(transact.Booked())
+	}
+	s.Net = s.Net.Add(transact.Signed())
+}
+
+// MonthSummary sums up a calendar month.
+type MonthSummary struct {
+	Year  int
+	Month time.Month
+	Summary
+}
+
+// WeekSummary sums up an ISO 8601 week.
+type WeekSummary struct {
+	Year, Week int
+	Summary
+}
+
+// SummarizeByMonth groups the transactions by calendar month, oldest first.
+func SummarizeByMonth(database Database) []MonthSummary {
+	months := make(map[[2]int]*MonthSummary)
+	for _, transact := range database.Transactions {
+		key := [2]int{transact.Date.Year(), int(transact.Date.Month())}
+		if months[key] == nil {
+			months[key] = &MonthSummary{Year: key[0], Month: time.Month(key[1])}
+		}
+		months[key].add(transact)
+	}
+	summaries := make([]MonthSummary, 0, len(months))
+	for _, summary := range months {
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Year == summaries[j].Year {
+			return summaries[i].Month < summaries[j].Month
+		}
+		return summaries[i].Year < summaries[j].Year
+	})
+	return summaries
+}
+
+// SummarizeByWeek groups the transactions by ISO year and week, oldest first.
+// Days around new year may belong to a week of the neighbouring year.
+func SummarizeByWeek(database Database) []WeekSummary {
+	weeks := make(map[[2]int]*WeekSummary)
+	for _, transact := range database.Transactions {
+		year, week := transact.Date.ISOWeek()
+		key := [2]int{year, week}
+		if weeks[key] == nil {
+			weeks[key] = &WeekSummary{Year: year, Week: week}
+		}
+		weeks[key].add(transact)
+	}
+	summaries := make([]WeekSummary, 0, len(weeks))
+	for _, summary := range weeks {
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Year == summaries[j].Year {
+			return summaries[i].Week < summaries[j].Week
+		}
+		return summaries[i].Year < summaries[j].Year
+	})
+	return summaries
+}
+
+// CategorySummary sums up a category.
+type CategorySummary struct {
+	Category string
+	Summary
+}
+
+// SummarizeByCategory groups the transactions by category,
+// the largest withdrawals first and equal ones by name. Splits count towards their categories.
+func SummarizeByCategory(database Database) []CategorySummary {
+	categories := make(map[string]*CategorySummary)
+	for _, transact := range SplitParts(database.Transactions) {
+		if categories[transact.Category] == nil {
+			categories[transact.Category] = &CategorySummary{Category: transact.Category}
+		}
+		categories[transact.Category].add(transact)
+	}
+	summaries := make([]CategorySummary, 0, len(categories))
+	for _, summary := range categories {
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Withdrawals == summaries[j].Withdrawals {
+			return summaries[i].Category < summaries[j].Category
+		}
+		return summaries[i].Withdrawals.Larger(summaries[j].Withdrawals)
+	})
+	return summaries
+}
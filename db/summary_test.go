@@ -0,0 +1,46 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarizeByWeekAcrossNewYear(t *testing.T) {
+	database := NewDatabase("test")
+	for _, date := range []time.Time{
+		time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2015, 12, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2016, 1, 4, 0, 0, 0, 0, time.UTC),
+		time.Date(2018, 12, 31, 0, 0, 0, 0, time.UTC),
+	} {
+		database.Store(NewTransaction("Coffee", Withdraw, 250, date))
+	}
+	want := []struct{ year, week, count int }{
+		{2015, 53, 2},
+		{2016, 1, 1},
+		{2019, 1, 2},
+	}
+	weeks := SummarizeByWeek(database)
+	if len(weeks) != len(want) {
+		t.Fatalf("got %+v, want %+v", weeks, want)
+	}
+	for i, w := range want {
+		if weeks[i].Year != w.year || weeks[i].Week != w.week || weeks[i].Count != w.count || weeks[i].Net != Value(-250*w.count) {
+			t.Errorf("got %+v, want week %d of %d with %d transactions", weeks[i], w.week, w.year, w.count)
+		}
+	}
+}
+
+func TestSummarizeByMonthAcrossNewYear(t *testing.T) {
+	database := NewDatabase("test")
+	database.Store(NewTransaction("Salary", Deposit, 100000, time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)))
+	database.Store(NewTransaction("Coffee", Withdraw, 250, time.Date(2015, 12, 31, 23, 59, 0, 0, time.UTC)))
+	months := SummarizeByMonth(database)
+	if len(months) != 2 || months[0].Year != 2015 || months[0].Month != time.December || months[1].Year != 2016 || months[1].Month != time.January {
+		t.Fatalf("got %+v, want December 2015 and January 2016", months)
+	}
+	if months[0].Withdrawals != 250 || months[1].Deposits != 100000 {
+		t.Errorf("got %+v", months)
+	}
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseTags(t *testing.T) {
+	if got := ParseTags(" food, ,travel ,"); !reflect.DeepEqual(got, []string{"food", "travel"}) {
+		t.Errorf("got %q, want [food travel]", got)
+	}
+	if got := ParseTags(""); got != nil {
+		t.Errorf("got %q, want no tags", got)
+	}
+}
+
+func TestMatchTags(t *testing.T) {
+	transact := Transaction{Tags: []string{"food", "travel"}}
+	tests := []struct {
+		tags []string
+		any  bool
+		want bool
+	}{
+		{nil, false, true},
+		{[]string{"food"}, false, true},
+		{[]string{"food", "work"}, false, false},
+		{[]string{"food", "work"}, true, true},
+		{[]string{"work"}, true, false},
+	}
+	for _, test := range tests {
+		if got := transact.MatchTags(test.tags, test.any); got != test.want {
+			t.Errorf("MatchTags(%q, %v): got %v, want %v", test.tags, test.any, got, test.want)
+		}
+	}
+}
+
+func TestTagsRoundTrip(t *testing.T) {
+	database := NewDatabase("test")
+	transact := NewTransaction("Flight", Withdraw, 25000, time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC))
+	transact.Tags = []string{"travel", "work"}
+	database.Store(transact)
+	path := filepath.Join(t.TempDir(), "test.trdb")
+	if err := WriteFile(path, database); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := decoded.Transactions[0].Tags; !reflect.DeepEqual(got, transact.Tags) {
+		t.Errorf("got tags %q, want %q", got, transact.Tags)
+	}
+}
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// ranked is a transaction with its position in the input, used to break ties.
+type ranked struct {
+	transact Transaction
+	index    int
+}
+
+// less ranks a below b if it is smaller or, for equal amounts, comes later.
+func (a ranked) less(b ranked) bool {
+	if x, y := abs(a.transact.Amount), abs(b.transact.Amount); x != y {
+		return x < y
+	}
+	return a.index > b.index
+}
+
+// rankHeap keeps the lowest ranked transaction on top.
+type rankHeap []ranked
+
+func (h rankHeap) Len() int            { return len(h) }
+func (h rankHeap) Less(i, j int) bool  { return h[i].less(h[j]) }
+func (h rankHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *rankHeap) Push(x interface{}) { *h = append(*h, x.(ranked)) }
+func (h *rankHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// Top returns the n transactions with the largest absolute amounts matching the
+// predicate, largest first. Equal amounts keep their original order. A nil predicate
+// matches every transaction.
+func Top(ts []Transaction, n int, pred func(Transaction) bool) []Transaction {
+	if n <= 0 {
+		return nil
+	}
+	h := make(rankHeap, 0, n)
+	for i, transact := range ts {
+		if pred != nil && !pred(transact) {
+			continue
+		}
+		r := ranked{transact, i}
+		if h.Len() < n {
+			heap.Push(&h, r)
+		} else if h[0].less(r) {
+			h[0] = r
+			heap.Fix(&h, 0)
+		}
+	}
+	sort.Slice(h, func(i, j int) bool { return h[j].less(h[i]) })
+	top := make([]Transaction, len(h))
+	for i, r := range h {
+		top[i] = r.transact
+	}
+	return top
+}
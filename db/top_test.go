@@ -0,0 +1,53 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func transactionNames(ts []Transaction) []string {
+	var names []string
+	for _, transact := range ts {
+		names = append(names, transact.Name)
+	}
+	return names
+}
+
+func TestTop(t *testing.T) {
+	date := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+	ts := []Transaction{
+		NewTransaction("Coffee", Withdraw, 250, date),
+		NewTransaction("Rent", Withdraw, 50000, date),
+		NewTransaction("Lunch", Withdraw, 1250, date),
+		NewTransaction("Refund", Deposit, 1250, date),
+		NewTransaction("Salary", Deposit, 100000, date),
+		NewTransaction("Dinner", Withdraw, 1250, date),
+	}
+	withdrawals := func(t Transaction) bool { return t.Type == Withdraw }
+	tests := []struct {
+		name string
+		n    int
+		pred func(Transaction) bool
+		want []string
+	}{
+		{"largest", 2, nil, []string{"Salary", "Rent"}},
+		{"ties keep their order", 5, nil, []string{"Salary", "Rent", "Lunch", "Refund", "Dinner"}},
+		{"tie at the cut", 3, nil, []string{"Salary", "Rent", "Lunch"}},
+		{"n larger than the data", 10, nil, []string{"Salary", "Rent", "Lunch", "Refund", "Dinner", "Coffee"}},
+		{"predicate", 3, withdrawals, []string{"Rent", "Lunch", "Dinner"}},
+		{"zero", 0, nil, nil},
+	}
+	for _, test := range tests {
+		got := transactionNames(Top(ts, test.n, test.pred))
+		if len(got) != len(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+)
+
+var (
+	// Transfers must move a positive amount of money.
+	errInvalidTransfer = newClassError(ErrValidation, "invalid transfer: amount must be positive")
+	// Transfers must move money between two distinct databases.
+	errSameDatabase = newClassError(ErrValidation, "invalid transfer: source and destination are the same database")
+)
+
+// newTransferID generates a random ID linking both sides of a transfer.
+func newTransferID() (string, error) {
+	bytes := make([]byte, 8)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// Transfer withdraws the amount, given in the book currency of src, from src and deposits it
+// into dst, converted into the book currency of dst. Both transactions share the same TransferID.
+func Transfer(src, dst *Database, amount Value, name string, date time.Time) error {
+	if !amount.Larger(ZeroValue) {
+		return errInvalidTransfer
+	}
+	from, to := src.BookCurrency(), dst.BookCurrency()
+	received, ok := Convert(amount, from, to)
+	if !ok {
+		return fmt.Errorf("%w from %s to %s", errMissingRate, from.Name, to.Name)
+	}
+	ID, err := newTransferID()
+	if err != nil {
+		return err
+	}
+	withdrawal := NewTransaction(name, Withdraw, amount, date)
+	withdrawal.TransferID = ID
+	deposit := NewTransaction(name, Deposit, received, date)
+	deposit.TransferID = ID
+	src.Store(withdrawal)
+	dst.Store(deposit)
+	return nil
+}
+
+// TransferFiles records a transfer between the databases at the given paths.
+// If either write fails, both databases are restored to their previous state.
+func TransferFiles(srcPath, dstPath string, amount Value, name string, date time.Time) error {
+	same, err := sameFile(srcPath, dstPath)
+	if err != nil {
+		return err
+	}
+	if same {
+		return errSameDatabase
+	}
+	src, err := OpenFile(srcPath)
+	if err != nil {
+		return err
+	}
+	dst, err := OpenFile(dstPath)
+	if err != nil {
+		return err
+	}
+	srcBackup, dstBackup := src, dst
+	srcBackup.Transactions = append([]Transaction(nil), src.Transactions...)
+	dstBackup.Transactions = append([]Transaction(nil), dst.Transactions...)
+	err = Transfer(&src, &dst, amount, name, date)
+	if err != nil {
+		return err
+	}
+	err = WriteFile(srcPath, src)
+	if err != nil {
+		return rollback(err, srcPath, srcBackup)
+	}
+	err = WriteFile(dstPath, dst)
+	if err != nil {
+		if rerr := rollback(err, srcPath, srcBackup); rerr != err {
+			return rerr
+		}
+		return rollback(err, dstPath, dstBackup)
+	}
+	return nil
+}
+
+// rollback restores the database at path after a failed transfer.
+// It returns the original error, annotated if the restore failed as well.
+func rollback(err error, path string, backup Database) error {
+	if rerr := WriteFile(path, backup); rerr != nil {
+		return fmt.Errorf("%w (restoring %s failed: %v)", err, path, rerr)
+	}
+	return err
+}
+
+// sameFile reports whether both paths resolve to the same existing file.
+func sameFile(a, b string) (bool, error) {
+	if a == StdioPath || b == StdioPath {
+		return a == b, nil
+	}
+	aInfo, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	bInfo, err := os.Stat(b)
+	if err != nil {
+		return false, err
+	}
+	return os.SameFile(aInfo, bInfo), nil
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTestDatabase(t *testing.T, path string, deposit Value) {
+	t.Helper()
+	database := NewDatabase(filepath.Base(path))
+	database.Store(NewTransaction("Initial", Deposit, deposit, time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)))
+	if err := WriteFile(path, database); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransferFiles(t *testing.T) {
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "src.trdb"), filepath.Join(dir, "dst.trdb")
+	writeTestDatabase(t, src, 10000)
+	writeTestDatabase(t, dst, 0)
+	if err := TransferFiles(src, dst, 2500, "Savings", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]Value{src: 7500, dst: 2500} {
+		database, err := OpenFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := database.Balance(); got != want {
+			t.Errorf("%s: got balance %v, want %v", filepath.Base(path), got, want)
+		}
+	}
+}
+
+func TestTransferFilesSameDatabase(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.trdb")
+	writeTestDatabase(t, path, 10000)
+	if err := os.Symlink(path, filepath.Join(dir, "b.trdb")); err != nil {
+		t.Fatal(err)
+	}
+	for _, other := range []string{path, filepath.Join(dir, ".", "a.trdb"), filepath.Join(dir, "b.trdb")} {
+		err := TransferFiles(path, other, 2500, "Savings", time.Now())
+		if !errors.Is(err, ErrValidation) {
+			t.Errorf("transfer to %s: got %v, want validation error", other, err)
+		}
+	}
+	database, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := database.Balance(); got != 10000 {
+		t.Errorf("got balance %v, want unchanged 10000", got)
+	}
+}
+
+func TestTransferCurrencies(t *testing.T) {
+	// A currency of its own keeps the exchange rate from leaking into other tests.
+	crown := Currency{Name: "TransferCrown", Format: "%d.%0*d", Ratio: 100, Symbol: " kr"}
+	RegisterCurrency(crown)
+	src, dst := NewDatabase("src"), NewDatabase("dst")
+	dst.Currency = crown.Name
+	if err := Transfer(&src, &dst, 1000, "Savings", time.Now()); !errors.Is(err, ErrValidation) {
+		t.Errorf("without a rate: got %v, want validation error", err)
+	}
+	if src.Size() != 0 || dst.Size() != 0 {
+		t.Fatalf("without a rate: got %d and %d transactions, want none", src.Size(), dst.Size())
+	}
+	SetRate(Euro.Name, crown.Name, 10)
+	if err := Transfer(&src, &dst, 1000, "Savings", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if src.Balance() != -1000 || dst.Balance() != 10000 {
+		t.Errorf("got balances %v and %v, want -1000 and 10000", int(src.Balance()), int(dst.Balance()))
+	}
+}
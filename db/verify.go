@@ -0,0 +1,30 @@
+package db
+
+import "fmt"
+
+// Verify checks every transaction in the database and returns all problems found.
+func Verify(database Database) []error {
+	var problems []error
+	for id, transact := range database.Transactions {
+		for _, err := range transact.problems() {
+			problems = append(problems, fmt.Errorf("transaction #%d: %w", id, err))
+		}
+	}
+	return problems
+}
+
+// Repair drops every transaction failing Validate and returns how many were removed.
+// The IDs of the following transactions shift accordingly.
+func Repair(database *Database) (removed int) {
+	valid := make([]Transaction, 0, len(database.Transactions))
+	for _, transact := range database.Transactions {
+		if transact.Validate() != nil {
+			removed++
+			continue
+		}
+		valid = append(valid, transact)
+	}
+	database.Transactions = valid
+	database.recompute()
+	return removed
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// invalidDatabase holds a valid transaction followed by one with every problem Verify finds.
+func invalidDatabase() Database {
+	database := NewDatabase("test")
+	database.Transactions = []Transaction{
+		NewTransaction("Coffee", Withdraw, 250, time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)),
+		{Name: " ", Type: "steal", Amount: -100},
+	}
+	database.recompute()
+	return database
+}
+
+func TestVerify(t *testing.T) {
+	problems := Verify(invalidDatabase())
+	want := []error{errEmptyName, errInvalidAction, errNegativeAmount, errMissingDate}
+	if len(problems) != len(want) {
+		t.Fatalf("got %v, want %d problems", problems, len(want))
+	}
+	for i, err := range want {
+		if !errors.Is(problems[i], err) || !errors.Is(problems[i], ErrValidation) {
+			t.Errorf("got %v, want %v", problems[i], err)
+		}
+	}
+	if problems := Verify(NewDatabase("empty")); len(problems) != 0 {
+		t.Errorf("got %v for an empty database", problems)
+	}
+}
+
+func TestRepair(t *testing.T) {
+	database := invalidDatabase()
+	database.Transactions = append(database.Transactions,
+		NewTransaction("Salary", Deposit, 100000, time.Date(2016, 3, 2, 0, 0, 0, 0, time.UTC)),
+		Transaction{Name: "Undated", Type: Withdraw, Amount: 100})
+	database.recompute()
+	if removed := Repair(&database); removed != 2 {
+		t.Errorf("got %d removed, want 2", removed)
+	}
+	if got := transactionNames(database.Transactions); len(got) != 2 || got[0] != "Coffee" || got[1] != "Salary" {
+		t.Errorf("got %v, want Coffee and Salary", got)
+	}
+	if database.Balance() != 99750 {
+		t.Errorf("got balance %v, want 997.50", database.Balance())
+	}
+	if removed := Repair(&database); removed != 0 {
+		t.Errorf("repairing again removed %d", removed)
+	}
+}
@@ -2,262 +2,2328 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/lnsp/transaction/db"
 	"github.com/metakeule/fmtdate"
 	"github.com/urfave/cli"
 )
 
+// Exit codes of the process, scripts may rely on them.
+const (
+	// Any failure not covered by a more specific code.
+	exitGeneric = 1
+	// The database or a transaction does not exist.
+	exitNotFound = 2
+	// A transaction or an input value is invalid.
+	exitValidation = 3
+	// The database file cannot be decoded.
+	exitCorrupt = 4
+)
+
 const (
 	// HeaderSymbol used for displaying table hreaders.
 	tableHeaderSymbol = "="
+	// Clears the terminal and moves the cursor home.
+	clearScreenSequence = "\033[H\033[2J"
+	// ANSI sequences for colorized table output.
+	colorGreen = "\033[32m"
+	colorRed   = "\033[31m"
+	colorBold  = "\033[1m"
+	colorReset = "\033[0m"
+	// Placeholder for amounts without a known exchange rate.
+	unconvertibleAmount = "n/a"
+	// Shown if amounts in several currencies cannot be summed up.
+	missingRateMessage = "%v, pass --rate to convert the amounts"
+	// Minimum width of the amount column.
+	minAmountWidth = 12
+	// Default width of transaction tables.
+	defaultTableWidth = 94
+	// Width of the ID, type and separator columns.
+	tableFixedWidth = 26
+	// Default and minimum widths of the date and name columns.
+	defaultDateWidth = 24
+	defaultNameWidth = 20
+	minDateWidth     = 10
+	minNameWidth     = 8
 	// TimeFormat to display transaction timestamps.
 	transactionTimeFormat = "%02d. %s %04d %02d:%02d"
+	// Named date formats selectable by --date-format.
+	isoTimeFormat = "YYYY-MM-DD hh:mm"
+	usTimeFormat  = "MM/DD/YYYY hh:mm"
 
+	unknownCommandMessage    = "unknown command '%s', see 'transaction help'\n"
+	unknownRoundingMessage   = "unknown rounding '%s' (use half-up, half-even or truncate)"
+	unknownStyleMessage      = "unknown table style '%s' (use ascii, markdown, plain or compact)"
 	abortedMessage           = "Action aborted."
-	wipeDatabaseConfirmation = "A database already exists. Are you sure you want to do this? (y / N): "
-	wipeDatabaseYes          = "y"
-	wipeDatabaseNo           = "n"
+	confirmYes               = "y"
+	confirmYesLong           = "yes"
+	noDatabaseMessage        = "No database found. Run 'transaction init' first."
+	readOnlyDatabaseMessage  = "The database was read from stdin and cannot be modified, use a file path with --db instead."
+	corruptDatabaseMessage   = "The database cannot be read: %v"
+	endOfInputMessage        = "Unexpected end of input, the transaction was not stored."
+	wipeDatabaseConfirmation = "A database already exists at '%s'. Are you sure you want to do this? (y / N): "
 
 	databaseNameField      = "Database name: "
-	createdDatabaseMessage = "Created the database '%s'.\n"
+	createdDatabaseMessage = "Created the database '%s' at '%s'.\n"
 
 	transactionNameField      = "Transaction name: "
-	transactionTypeField      = "Transaction type (wd / dp): "
-	transactionDateField      = "Transaction date: "
+	transactionTypeField      = "Transaction type (wd, withdraw, draw, - / dp, deposit, depo, +) [wd]: "
+	transactionDateField      = "Transaction date (D.M.YYYY, optionally with hh:mm): "
 	transactionDateFormat     = "D.M.YYYY"
+	transactionDateTimeFormat = "D.M.YYYY hh:mm"
 	transactionTypeWithdraw   = "wd"
 	transactionTypeDeposit    = "dp"
 	transactionAmountField    = "Transaction amount: "
+	transactionCategoryField  = "Transaction category (optional): "
+	transactionTagsField      = "Transaction tags (optional, comma-separated): "
+	transactionNoteField      = "Transaction note (optional): "
+	transactionCurrencyField  = "Transaction currency (optional): "
+	futureDateMessage         = "the date %s lies in the future (drop --no-future to store it anyway)"
 	transactionSuccessMessage = "Stored the %s transaction '%s' (%s).\n"
 
-	wipeTransactionYes          = "y"
+	duplicateTransactionConfirmation = "A matching transaction [#%d] already exists. Store anyway? (y / N) "
+
+	unknownDirectionMessage  = "unknown direction '%s' (use in or out)"
+	directionConflictMessage = "--direction and --type select different transactions"
+	sinceConflictMessage     = "--since and --from cannot be combined"
+	filterTotalsMessage      = "deposits: %s, withdrawals: %s, net: %s\n"
+	unknownTypeMessage       = "unknown transaction type '%s' (use wd / withdraw / draw / - or dp / deposit / depo / +)"
+
+	invalidTransactionIDMessage  = "invalid transaction ID '%s'"
+	missingTransactionMessage    = "transaction #%d: %v"
+	outOfRangeTransactionMessage = "invalid ID: transaction #%d does not exist (valid IDs are 0 to %d)"
+
 	wipeTransactionNo           = "n"
 	wipeTransactionConfirmation = "\nAre you sure? (y / N) "
 	wipeTransactionSuccess      = "Transaction deleted."
+	dryRunHeader                = "Dry run (would delete)"
+	dryRunMessage               = "Deleting would change the balance by %s. Nothing was deleted.\n"
+
+	budgetMonthFormat     = "M.YYYY"
+	budgetSuccessMessage  = "Set the monthly budget of '%s' to %s.\n"
+	budgetOverBudgetLabel = "over budget"
+	budgetMarker          = " *"
+	budgetInvalidMessage  = "invalid budget limit '%s', expected a non-negative amount"
+
+	tagsNoCategory = "(none)"
+
+	transferDefaultName    = "Transfer"
+	transferSuccessMessage = "Transferred %s from '%s' to '%s'.\n"
+
+	verifySuccessMessage = "The database '%s' is valid (%d transactions).\n"
+	verifyFailureMessage = "Found %d problems."
+
+	repairConfirmation   = "This will remove %d invalid transactions and renumber the others. Are you sure? (y / N) "
+	repairNothingMessage = "The database '%s' has no invalid transactions.\n"
+	repairSuccessMessage = "Removed %d invalid transactions, the database now holds %d transactions.\n"
+
+	backupFileFormat     = "transaction-%s.trdb"
+	backupTimeFormat     = "20060102-150405"
+	backupSuccessMessage = "Saved a backup to '%s'.\n"
+
+	restoreConfirmation   = "This will replace the current database. Are you sure? (y / N) "
+	restoreSuccessMessage = "Restored the database from '%s'.\n"
+
+	summaryPeriodMonth = "month"
+	summaryPeriodWeek  = "week"
+
+	clearConfirmation   = "This will delete all %d transactions. Are you sure? (y / N) "
+	clearSuccessMessage = "Deleted all transactions of '%s'.\n"
+
+	duplicateFieldFormat    = "%s [%s]: "
+	duplicateSuccessMessage = "Stored a copy of transaction [#%d] as [#%d].\n"
+
+	countMessage = "%s contains %d matching transactions.\n"
+
+	exportFormatQIF      = "qif"
+	exportFormatCSV      = "csv"
+	unknownLocaleMessage = "unknown locale '%s' (use en or de)"
+
+	bulkRenameMessage    = "Renamed %d transactions from '%s' to '%s'.\n"
+	renameSuccessMessage = "Renamed the database '%s' to '%s'.\n"
+
+	balanceMessage      = "%s has a balance of %s.\n"
+	rawPerDayMessage    = "--raw and --decimal print a single balance and cannot be combined with --per-day"
+	balanceFormatCSV    = "csv"
+	balanceFormatJSON   = "json"
+	balanceSeriesLayout = "2006-01-02"
+
+	reportMonthLayout = "2006-01"
+	reportEmptyKey    = "(none)"
+
+	defaultEditor        = "vi"
+	editTempPattern      = "transaction-*.json"
+	editInvalidMessage   = "The edited database is invalid, the original was kept."
+	editUnchangedMessage = "No changes were made."
+	editSuccessMessage   = "Saved %d transactions.\n"
+
+	emptyDatabaseMessage  = "The database '%s' holds no transactions yet.\n"
+	compactSuccessMessage = "Compacted the database at '%s'.\n"
+
+	averageMessage = "%s averages %s per month over %.1f months.\n"
+
+	mergeSuccessMessage      = "Merged %d of %d transactions from '%s', the database now holds %d transactions.\n"
+	reconcileSuccessMessage  = "%s matches all %d transactions of the statement.\n"
+	reconcileRejectedMessage = "Skipped %d malformed rows of the statement.\n"
+
+	batchLineMessage    = "line %d: %v\n"
+	batchFailedMessage  = "%d of %d lines are invalid, no transactions were stored."
+	batchSuccessMessage = "Stored %d transactions, the database now holds %d transactions.\n"
+
+	invalidSplitMessage = "invalid --split '%s', expected category=amount"
+
+	infoFieldFormat = "%-14s %v\n"
+	infoStdinPath   = "(stdin)"
+
+	importFormatOFX               = "ofx"
+	importFormatCSV               = "csv"
+	importCurrencyMismatchMessage = "The statement is in %s but the book is in %s, nothing was imported. Pass --assume-currency to choose the currency of the amounts."
+	importSuccessMessage          = "Read %d entries: imported %d, skipped %d duplicates, rejected %d invalid. The database now holds %d transactions.\n"
 )
 
 var (
 	console = bufio.NewReader(os.Stdin)
-)
-
-func isTypeDeposit(text string) bool {
-	lc := strings.ToLower(strings.TrimSpace(text))
-	return lc == "dp" || lc == "deposit" || lc == "depo"
-}
+	// Total width of transaction tables.
+	tableWidth = defaultTableWidth
+	// Style of printed tables (ascii, markdown or plain).
+	tableStyle = tableStyleASCII
+	// Whether tables are printed with ANSI colors.
+	colorEnabled = false
+	// Whether tables of transactions end with a header and the total, set by --no-header.
+	tableHeaders = true
+	// Whether amounts in tables carry the sign of their type, set by --signed.
+	signedAmounts = false
+	// Language of displayed month names, e.g. "de".
+	displayLocale = ""
+	// Custom fmtdate pattern for displaying timestamps, empty for the default format.
+	displayTimeFormat = ""
 
-func isTypeWithdraw(text string) bool {
-	lc := strings.ToLower(strings.TrimSpace(text))
-	return lc == "wd" || lc == "withdraw" || lc == "draw"
-}
+	currencyFlag = cli.StringFlag{
+		Name:  "currency, c",
+		Value: "",
+		Usage: "Display amounts in another currency (euro, dollar, ...)",
+	}
+	rateFlag = cli.StringSliceFlag{
+		Name:  "rate",
+		Usage: "Exchange rate of a currency into the display currency (e.g. dollar=0.92)",
+	}
+	sinceFlag = cli.StringFlag{
+		Name:  "since",
+		Value: "",
+		Usage: "Only show transactions of the last 30d, 2w, 1m, 1y, ...",
+	}
+	rawFlag = cli.BoolFlag{
+		Name:  "raw",
+		Usage: "Print only the amounts in minor units (e.g. 1250)",
+	}
+	decimalFlag = cli.BoolFlag{
+		Name:  "decimal",
+		Usage: "Print only the amounts as plain decimals (e.g. 12.50)",
+	}
+	outputFlag = cli.StringFlag{
+		Name:  "output, o",
+		Value: "",
+		Usage: "Write the output to a file instead of stdout",
+	}
+	yesFlag = cli.BoolFlag{
+		Name:  "yes, y",
+		Usage: "Answer the confirmation with yes",
+	}
+	reverseFlag = cli.BoolFlag{
+		Name:  "reverse, r",
+		Usage: "Show the newest transactions first",
+	}
+)
 
 func initAction(c *cli.Context) error {
-	if db.Exists() && !c.Bool("force") {
-		fmt.Print(wipeDatabaseConfirmation)
-		status := wipeDatabaseNo
-		fmt.Scanf("%s")
-		if status != wipeDatabaseYes {
-			fmt.Println(abortedMessage)
-			return nil
+	if db.Exists() && !confirm(fmt.Sprintf(wipeDatabaseConfirmation, db.Path()), c.Bool("force") || c.Bool("yes")) {
+		fmt.Println(abortedMessage)
+		return nil
+	}
+	currency := db.Euro
+	if c.String("currency") != "" {
+		var err error
+		currency, err = db.LookupCurrency(c.String("currency"))
+		if err != nil {
+			return exitError(err)
 		}
 	}
 	fmt.Print(databaseNameField)
 	name, _ := getInput()
 	database := db.NewDatabase(name)
+	database.Currency = currency.Name
 	err := db.Write(database)
 	if err != nil {
 		return err
 	}
-	fmt.Printf(createdDatabaseMessage, name)
+	fmt.Printf(createdDatabaseMessage, name, db.Path())
 	return nil
 }
 
+// parseAction maps a transaction type alias to its action, or "" if unknown.
+// The aliases are the same as in imported CSV files.
+func parseAction(text string) db.Action {
+	return db.ParseAction(text)
+}
+
 func storeAction(c *cli.Context) error {
-	var name string
+	name, action := c.String("name"), parseAction(c.String("type"))
+	amount, _, err := parseAmountFlag(c, "amount")
+	if err != nil {
+		return err
+	}
+	// Only ask for optional fields if some required field is missing.
+	interactive := name == "" || action == "" || amount == db.ZeroValue
 	for name == "" {
 		fmt.Print(transactionNameField)
-		name, _ = getInput()
+		input, err := getInput()
+		if err != nil {
+			return inputError(err)
+		}
+		name = input
 	}
-	var date time.Time
-	fmt.Print(transactionDateField)
-	dateStr, _ := getInput()
-	date, err := fmtdate.Parse(transactionDateFormat, dateStr)
-	if err != nil {
+	dateStr := c.String("date")
+	if dateStr == "" && interactive {
+		fmt.Print(transactionDateField)
+		dateStr, _ = getInput()
+	}
+	date, err := parseTransactionDate(dateStr)
+	if err != nil && c.String("date") != "" {
+		return cli.NewExitError(fmt.Sprintf("invalid --date '%s', expected %s or %s", dateStr, transactionDateFormat, transactionDateTimeFormat), exitValidation)
+	} else if err != nil {
 		date = time.Now()
 	}
-	var action db.Action
+	if c.Bool("no-future") {
+		if isFutureDate(date, time.Now()) {
+			return cli.NewExitError(fmt.Sprintf(futureDateMessage, formatTime(date)), exitValidation)
+		}
+	}
 	for action == "" {
 		fmt.Print(transactionTypeField)
-		actionString, _ := getInput()
-		if isTypeWithdraw(actionString) {
-			action = db.Withdraw
-		} else if isTypeDeposit(actionString) {
-			action = db.Deposit
-		} else {
-			action = ""
+		actionString, err := getInput()
+		if err != nil {
+			return inputError(err)
+		}
+		if actionString == "" {
+			actionString = transactionTypeWithdraw
 		}
+		action = parseAction(actionString)
 	}
-	var amount db.Value
 	for amount == 0 {
 		fmt.Print(transactionAmountField)
-		amountString, _ := getInput()
+		amountString, err := getInput()
+		if err != nil {
+			return inputError(err)
+		}
 		amount = db.Parse(amountString)
 	}
+	category, tags, note := c.String("category"), c.String("tags"), c.String("note")
+	if category == "" && interactive {
+		fmt.Print(transactionCategoryField)
+		category, _ = getInput()
+	}
+	if tags == "" && interactive {
+		fmt.Print(transactionTagsField)
+		tags, _ = getInput()
+	}
+	if note == "" && interactive {
+		fmt.Print(transactionNoteField)
+		note, _ = getInput()
+	}
+	currencyName := c.String("currency")
+	if currencyName == "" && interactive {
+		fmt.Print(transactionCurrencyField)
+		currencyName, _ = getInput()
+	}
+	var currency db.Currency
+	if currencyName != "" {
+		currency, err = db.LookupCurrency(currencyName)
+		if err != nil {
+			return exitError(err)
+		}
+	}
 	transact := db.NewTransaction(name, action, amount, date)
-	err = db.Store(transact)
+	transact.Category = category
+	transact.Tags = db.ParseTags(tags)
+	transact.Note = note
+	transact.Currency = currency.Name
+	transact.Reversal = c.Bool("reversal")
+	for _, split := range c.StringSlice("split") {
+		category, amount, ok := parseSplit(split)
+		if !ok {
+			return cli.NewExitError(fmt.Sprintf(invalidSplitMessage, split), exitValidation)
+		}
+		transact.Splits = append(transact.Splits, db.Split{Category: category, Amount: amount})
+	}
+	if err := transact.Validate(); err != nil {
+		return exitError(err)
+	}
+	if c.Bool("check-dupes") && !c.Bool("force") {
+		database, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		if id, ok := db.FindDuplicate(database, transact); ok {
+			if !confirm(fmt.Sprintf(duplicateTransactionConfirmation, id), false) {
+				fmt.Println(abortedMessage)
+				return nil
+			}
+		}
+	}
+	err = db.Append(transact)
 	if err != nil {
-		return err
+		return databaseError(err)
+	}
+	display := transact.CurrencyOf()
+	if transact.Currency == "" {
+		if book, err := db.OpenBookCurrency(); err == nil {
+			display = book
+		}
 	}
-	fmt.Printf(transactionSuccessMessage, action, name, amount.String())
+	fmt.Printf(transactionSuccessMessage, action, name, transact.Booked().Format(display))
 	return nil
 }
 
+// padLeft right-aligns the string in a column of the given width,
+// counting runes instead of bytes to support multi-byte currency symbols.
+func padLeft(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return strings.Repeat(" ", width-n) + s
+	}
+	return s
+}
+
 func limitString(s string, l int) string {
-	if len(s) < l {
-		return fmt.Sprintf("%"+strconv.Itoa(l)+"s", s)
+	if utf8.RuneCountInString(s) < l {
+		return padLeft(s, l)
 	}
 	return string([]rune(s)[:l])
 }
 
 func getTableHeader(headerText string) string {
+	if tableStyle != tableStyleASCII {
+		return headerText
+	}
 	header := headerText + "  "
-	for i := 0; i < 46; i++ {
+	for i := 0; i < tableWidth; i++ {
 		header += tableHeaderSymbol
 	}
-	return limitString(header, 82)
+	return limitString(header, tableWidth)
 }
 
-func printTransactionTable(header string, transactions map[int]db.Transaction) {
-	fmt.Println(getTableHeader(header))
-	var ids []int
-	for i := range transactions {
-		ids = append(ids, i)
+// terminalWidth reads the width from $COLUMNS, falling back to the default width.
+func terminalWidth() int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
 	}
-	sort.Ints(ids)
-	var balance db.Value
-	for _, id := range ids {
-		transact := transactions[id]
-		idString := "[#" + strconv.Itoa(id) + "]"
-		fmt.Printf("%6s  On %s %s :: %-8s %12s\n", idString, limitString(formatTime(transact.Date), 24), limitString(transact.Name, 20), transact.Type, transact.Amount)
+	return defaultTableWidth
+}
+
+// tableColumns splits the space left by the fixed columns between date and name,
+// keeping the proportions of the default layout.
+func tableColumns(amountWidth int) (dateWidth, nameWidth int) {
+	flexible := tableWidth - tableFixedWidth - 2*amountWidth
+	dateWidth = flexible * defaultDateWidth / (defaultDateWidth + defaultNameWidth)
+	if dateWidth < minDateWidth {
+		dateWidth = minDateWidth
+	}
+	nameWidth = flexible - dateWidth
+	if nameWidth < minNameWidth {
+		nameWidth = minNameWidth
+	}
+	return dateWidth, nameWidth
+}
+
+// signedAmount formats the amount with a + for deposits and a - for withdrawals,
+// the other way round for reversals.
+func signedAmount(t db.Transaction) string {
+	sign := "+"
+	if (t.Type == db.Withdraw) != t.Reversal {
+		sign = "-"
+	}
+	return sign + t.Amount.Abs().Format(t.CurrencyOf())
+}
+
+// tableEntry is a row of a transaction table.
+type tableEntry struct {
+	id       int
+	transact db.Transaction
+}
+
+// printTransactionTable prints the entries in the given order, or the other way round if reversed.
+func printTransactionTable(w io.Writer, header string, entries []tableEntry, reverse bool) {
+	if reverse {
+		reversed := make([]tableEntry, len(entries))
+		for i, entry := range entries {
+			reversed[len(entries)-1-i] = entry
+		}
+		entries = reversed
+	}
+	running, convertible := runningBalances(entries)
+	amounts := make([]string, len(entries))
+	balances := make([]string, len(entries))
+	amountWidth := minAmountWidth
+	for i, entry := range entries {
+		amounts[i] = entry.transact.Booked().Format(entry.transact.CurrencyOf())
+		if signedAmounts || tableStyle == tableStyleCompact {
+			amounts[i] = signedAmount(entry.transact)
+		}
+		balances[i] = unconvertibleAmount
+		if convertible {
+			balances[i] = running[i].String()
+		}
+		for _, amount := range []string{amounts[i], balances[i]} {
+			if n := utf8.RuneCountInString(amount); n > amountWidth {
+				amountWidth = n
+			}
+		}
+	}
+	var total db.Value
+	balanceString := unconvertibleAmount
+	if convertible {
+		for _, entry := range entries {
+			value, _ := normalizedAmount(entry.transact)
+			total = total.Add(value)
+		}
+		balanceString = total.String()
+	}
+	if n := utf8.RuneCountInString(balanceString); n > amountWidth {
+		amountWidth = n
+	}
+	renderer := newTableRenderer(tableStyle, w)
+	if !tableHeaders {
+		renderer = newRowsOnlyRenderer(tableStyle, w)
+	}
+	renderer.header(header, amountWidth)
+	for i, entry := range entries {
+		renderer.row(entry.id, entry.transact, amounts[i], balances[i], convertible && running[i].Smaller(db.ZeroValue))
+	}
+	renderer.footer(balanceString, convertible && total.Smaller(db.ZeroValue))
+}
+
+// colorize wraps the string in the given ANSI sequence if colors are enabled.
+// Strings should be padded before colorizing, as the sequences count towards the length.
+func colorize(s, color string, enabled bool) string {
+	if !enabled || color == "" {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// amountColor returns green for deposits and red for withdrawals.
+func amountColor(t db.Transaction) string {
+	if t.Type == db.Deposit {
+		return colorGreen
+	}
+	return colorRed
+}
+
+// isTerminal reports whether the file is connected to a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
 
-		switch transact.Type {
-		case db.Withdraw:
-			balance = balance.Add(-transact.Amount)
-		case db.Deposit:
-			balance = balance.Add(transact.Amount)
+// normalizedAmount converts the signed amount of the transaction into the display currency.
+func normalizedAmount(t db.Transaction) (db.Value, bool) {
+	return db.Convert(t.Signed(), t.CurrencyOf(), db.DefaultCurrency)
+}
+
+// runningBalances computes the balance after each entry in chronological order,
+// independent of the order the entries are displayed in. The balances are only
+// valid if all amounts are convertible into the display currency.
+func runningBalances(entries []tableEntry) ([]db.Value, bool) {
+	chronological := make([]int, len(entries))
+	for i := range chronological {
+		chronological[i] = i
+	}
+	sort.SliceStable(chronological, func(i, j int) bool {
+		a, b := entries[chronological[i]], entries[chronological[j]]
+		if a.transact.Date.Equal(b.transact.Date) {
+			return a.id < b.id
 		}
+		return a.transact.Date.Before(b.transact.Date)
+	})
+	running := make([]db.Value, len(entries))
+	convertible := true
+	var balance db.Value
+	for _, i := range chronological {
+		value, ok := normalizedAmount(entries[i].transact)
+		convertible = convertible && ok
+		balance = balance.Add(value)
+		running[i] = balance
 	}
-	fmt.Printf("%69s------------\n%69s%12s\n", "", "", balance)
+	return running, convertible
 }
 
-func listAction(c *cli.Context) error {
+// databaseError replaces a missing or read-only database error with a friendly message.
+func databaseError(err error) error {
+	if os.IsNotExist(err) {
+		return cli.NewExitError(noDatabaseMessage, exitNotFound)
+	}
+	if db.IsReadOnly(err) {
+		return cli.NewExitError(readOnlyDatabaseMessage, exitGeneric)
+	}
+	if errors.Is(err, db.ErrCorrupt) {
+		return cli.NewExitError(fmt.Sprintf(corruptDatabaseMessage, err), exitCorrupt)
+	}
+	return err
+}
+
+// nopCloser keeps stdout open when the output is closed.
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// openOutput opens the file given by the --output flag, creating its parent
+// directories as needed, or falls back to stdout. Colors are disabled for files.
+func openOutput(c *cli.Context) (io.WriteCloser, error) {
+	path := c.String("output")
+	if path == "" {
+		return nopCloser{os.Stdout}, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, cli.NewExitError(err.Error(), exitGeneric)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, cli.NewExitError(err.Error(), exitGeneric)
+	}
+	colorEnabled = false
+	return file, nil
+}
+
+// exitCode maps an error to the exit code of the process.
+func exitCode(err error) int {
+	if coder, ok := err.(cli.ExitCoder); ok {
+		return coder.ExitCode()
+	}
+	switch {
+	case errors.Is(err, db.ErrNotFound), errors.Is(err, os.ErrNotExist):
+		return exitNotFound
+	case errors.Is(err, db.ErrValidation):
+		return exitValidation
+	case errors.Is(err, db.ErrCorrupt):
+		return exitCorrupt
+	}
+	return exitGeneric
+}
+
+// exitError turns an error into an exit error with the matching exit code.
+func exitError(err error) error {
+	if _, ok := err.(cli.ExitCoder); ok || err == nil {
+		return err
+	}
+	return cli.NewExitError(err.Error(), exitCode(err))
+}
+
+// requireWritable refuses to run commands that change the database in place
+// if it is read from stdin.
+func requireWritable(c *cli.Context) error {
+	if db.Path() == db.StdioPath && !c.Bool("dry-run") {
+		return cli.NewExitError(readOnlyDatabaseMessage, exitGeneric)
+	}
+	return nil
+}
+
+// openDatabase opens the existing database or explains how to create one.
+// Amounts are displayed in the book currency of the database.
+func openDatabase() (db.Database, error) {
 	database, err := db.Open()
+	if err != nil {
+		return database, databaseError(err)
+	}
+	db.DefaultCurrency = database.BookCurrency()
+	return database, nil
+}
+
+// openDisplayDatabase opens the database and switches to the display currency and
+// exchange rates given by --currency and --rate.
+// The returned function restores the display currency from before the database was opened.
+func openDisplayDatabase(c *cli.Context) (db.Database, func(), error) {
+	previous := db.DefaultCurrency
+	restore := func() { db.DefaultCurrency = previous }
+	database, err := openDatabase()
+	if err != nil {
+		return database, restore, err
+	}
+	if err := useCurrency(c); err != nil {
+		return database, restore, err
+	}
+	return database, restore, useRates(c)
+}
+
+// convertDatabase converts all amounts into the display currency, so they can be summed up.
+func convertDatabase(database db.Database) (db.Database, error) {
+	converted, err := db.ConvertDatabase(database, db.DefaultCurrency)
+	if err != nil {
+		return converted, cli.NewExitError(fmt.Sprintf(missingRateMessage, err), exitValidation)
+	}
+	return converted, nil
+}
+
+// printTotals prints the deposits, withdrawals and net amount of the transactions
+// in the display currency, or n/a if some amount cannot be converted.
+func printTotals(w io.Writer, ts []db.Transaction) {
+	converted, err := db.ConvertAll(ts, db.DefaultCurrency)
+	if err != nil {
+		fmt.Fprintf(w, filterTotalsMessage, unconvertibleAmount, unconvertibleAmount, unconvertibleAmount)
+		return
+	}
+	deposits, withdrawals, net := db.Totals(converted)
+	fmt.Fprintf(w, filterTotalsMessage, deposits, withdrawals, net)
+}
+
+// useCurrency swaps the display currency if requested by the --currency flag.
+func useCurrency(c *cli.Context) error {
+	if c.String("currency") == "" {
+		return nil
+	}
+	currency, err := db.LookupCurrency(c.String("currency"))
 	if err != nil {
 		return err
 	}
-	idMap := make(map[int]db.Transaction)
-	startValue := database.Size() - c.Int("limit")
-	for id := database.Size() - 1; id >= 0 && id >= startValue; id-- {
-		transact, err := database.Read(id)
+	db.DefaultCurrency = currency
+	return nil
+}
+
+// useRates registers the exchange rates given by --rate into the display currency.
+func useRates(c *cli.Context) error {
+	for _, rate := range c.StringSlice("rate") {
+		parts := strings.SplitN(rate, "=", 2)
+		if len(parts) != 2 {
+			return cli.NewExitError(fmt.Sprintf("invalid rate '%s', expected currency=rate", rate), exitValidation)
+		}
+		currency, err := db.LookupCurrency(parts[0])
 		if err != nil {
 			return err
 		}
-		idMap[id] = transact
+		value, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || value <= 0 {
+			return cli.NewExitError(fmt.Sprintf("invalid rate '%s', expected a positive number", parts[1]), exitValidation)
+		}
+		db.SetRate(currency.Name, db.DefaultCurrency.Name, value)
 	}
-	header := fmt.Sprintf("%s (latest %d entries)", database.Name, len(idMap))
-	printTransactionTable(header, idMap)
 	return nil
 }
 
-func filterAction(c *cli.Context) error {
-	database, err := db.Open()
+func listAction(c *cli.Context) error {
+	since, err := parseSinceFlag(c)
 	if err != nil {
 		return err
 	}
-	namePredicate, maxPredicate, minPredicate, typePredicate := c.String("name"), db.Parse(c.String("max")), db.Parse(c.String("min")), c.String("type")
-	header := fmt.Sprintf("%s (name='%s', min='%s', max='%s', type='%s')", database.Name, namePredicate, minPredicate, maxPredicate, typePredicate)
-	idMap := make(map[int]db.Transaction)
-	for id := 0; id < database.Size(); id++ {
-		transact, err := database.Read(id)
-		if err != nil {
-			return err
+	database, restore, err := openDisplayDatabase(c)
+	defer restore()
+	if err != nil {
+		return err
+	}
+	out, err := openOutput(c)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	var w io.Writer = out
+	if c.String("output") == "" {
+		w = newPager(out, c.Int("page-size"))
+	}
+	return printLatest(w, database, c.Int("limit"), since, c.Bool("reverse"))
+}
+
+// printLatest shows the latest transactions dated on or after since,
+// a non-positive limit shows all.
+func printLatest(w io.Writer, database db.Database, limit int, since time.Time, reverse bool) error {
+	var entries []tableEntry
+	database.Each(func(id int, transact db.Transaction) bool {
+		if !transact.Date.Before(since) {
+			entries = append(entries, tableEntry{id, transact})
 		}
-		if namePredicate != "" && transact.Name != namePredicate {
-			continue
+		return true
+	})
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	marked, err := markOverBudget(database, entries)
+	if err != nil {
+		return err
+	}
+	header := fmt.Sprintf("%s (latest %d entries)", database.Name, len(entries))
+	printTransactionTable(w, header, entries, reverse)
+	if marked && tableHeaders {
+		fmt.Fprintf(w, "%s %s\n", strings.TrimSpace(budgetMarker), budgetOverBudgetLabel)
+	}
+	return nil
+}
+
+// markOverBudget appends the budget marker to the names of transactions booked to a category
+// that is over its limit in the month of the transaction, marked is true if any was marked.
+// Budgets are skipped if the amounts cannot be converted into the display currency.
+func markOverBudget(database db.Database, entries []tableEntry) (marked bool, err error) {
+	limits, err := db.OpenBudget()
+	if err != nil || len(limits) == 0 {
+		return false, err
+	}
+	database, err = db.ConvertDatabase(database, db.DefaultCurrency)
+	if err != nil {
+		return false, nil
+	}
+	type budgetKey struct {
+		month    time.Time
+		category string
+	}
+	over, months := make(map[budgetKey]bool), make(map[time.Time]bool)
+	for i, entry := range entries {
+		month := time.Date(entry.transact.Date.Year(), entry.transact.Date.Month(), 1, 0, 0, 0, 0, time.UTC)
+		if !months[month] {
+			months[month] = true
+			for _, line := range db.BudgetStatus(database, limits, month) {
+				over[budgetKey{month, line.Category}] = line.Over()
+			}
 		}
-		if maxPredicate != db.ZeroValue && maxPredicate.Smaller(transact.Amount) {
-			continue
+		for _, part := range entry.transact.Parts() {
+			if over[budgetKey{month, part.Category}] {
+				entries[i].transact.Name += budgetMarker
+				marked = true
+				break
+			}
 		}
-		if minPredicate != db.ZeroValue && minPredicate.Larger(transact.Amount) {
-			continue
+	}
+	return marked, nil
+}
+
+func watchAction(c *cli.Context) error {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	return watchDatabase(os.Stdout, c.Int("limit"), c.Duration("interval"), interrupt)
+}
+
+// watchDatabase renders the latest transactions whenever the database file changes,
+// polling its modification time until stop receives a signal.
+func watchDatabase(w io.Writer, limit int, interval time.Duration, stop <-chan os.Signal) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var lastModified time.Time
+	for {
+		info, err := os.Stat(db.Path())
+		if err != nil {
+			return databaseError(err)
 		}
-		if typePredicate != "" && ((isTypeDeposit(typePredicate) && transact.Type != db.Deposit) || (isTypeWithdraw(typePredicate) && transact.Type != db.Withdraw)) {
-			continue
+		if !info.ModTime().Equal(lastModified) {
+			lastModified = info.ModTime()
+			database, err := openDatabase()
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(w, clearScreenSequence)
+			err = printLatest(w, database, limit, time.Time{}, false)
+			if err != nil {
+				return err
+			}
+		}
+		select {
+		case <-stop:
+			fmt.Fprintln(w)
+			return nil
+		case <-ticker.C:
 		}
-		idMap[id] = transact
 	}
-	printTransactionTable(header, idMap)
-	return nil
 }
 
-func deleteAction(c *cli.Context) error {
-	ID, err := strconv.Atoi(c.Args().First())
+func filterAction(c *cli.Context) error {
+	database, restore, err := openDisplayDatabase(c)
+	defer restore()
 	if err != nil {
 		return err
 	}
-	transaction, err := db.Get(ID)
+	opts, err := filterOptions(c)
 	if err != nil {
 		return err
 	}
-	fmt.Print(transaction, wipeTransactionConfirmation)
-	confirmation, err := getInput()
+	header := fmt.Sprintf("%s (name='%s', min='%s', max='%s', type='%s', from='%s', to='%s')", database.Name, opts.Name, c.String("min"), c.String("max"), opts.Type, c.String("from"), c.String("to"))
+	if len(opts.Tags) > 0 {
+		header = strings.TrimSuffix(header, ")") + fmt.Sprintf(", tags='%s')", strings.Join(opts.Tags, ","))
+	}
+	if opts.Note != "" {
+		header = strings.TrimSuffix(header, ")") + fmt.Sprintf(", note='%s')", opts.Note)
+	}
+	if opts.Around != nil {
+		header = strings.TrimSuffix(header, ")") + fmt.Sprintf(", around='%s', tolerance='%s')", opts.Around, opts.Tolerance)
+	}
+	entries, filtered := filterEntries(database, opts)
+	out, err := openOutput(c)
 	if err != nil {
 		return err
 	}
-	if confirmation != wipeTransactionYes {
-		fmt.Println(abortedMessage)
+	defer out.Close()
+	printTransactionTable(out, header, entries, c.Bool("reverse"))
+	if !tableHeaders {
 		return nil
 	}
-	err = db.Delete(ID)
+	printTotals(out, filtered)
+	return nil
+}
+
+// filterOptions builds the transaction filter from the flags of the current command,
+// flags the command does not define match every transaction.
+func filterOptions(c *cli.Context) (db.FilterOptions, error) {
+	opts := db.FilterOptions{
+		Name:   c.String("name"),
+		Tags:   c.StringSlice("tag"),
+		AnyTag: c.Bool("any"),
+		Note:   c.String("note"),
+	}
+	max, ok, err := parseAmountFlag(c, "max")
 	if err != nil {
-		return err
+		return opts, err
+	} else if ok {
+		opts.Max = &max
 	}
-	fmt.Println(wipeTransactionSuccess)
-	return nil
+	min, ok, err := parseAmountFlag(c, "min")
+	if err != nil {
+		return opts, err
+	} else if ok {
+		opts.Min = &min
+	}
+	typePredicate := c.String("type")
+	if typePredicate != "" && parseAction(typePredicate) == "" {
+		return opts, cli.NewExitError(fmt.Sprintf(unknownTypeMessage, typePredicate), exitValidation)
+	}
+	opts.Type = parseAction(typePredicate)
+	if direction := strings.ToLower(c.String("direction")); direction != "" {
+		action, ok := filterDirections[direction]
+		if !ok {
+			return opts, cli.NewExitError(fmt.Sprintf(unknownDirectionMessage, direction), exitValidation)
+		}
+		if opts.Type != "" && opts.Type != action {
+			return opts, cli.NewExitError(directionConflictMessage, exitValidation)
+		}
+		opts.Type = action
+	}
+	opts.From, err = parseDateFlag(c, "from")
+	if err != nil {
+		return opts, err
+	}
+	if c.String("since") != "" {
+		if !opts.From.IsZero() {
+			return opts, cli.NewExitError(sinceConflictMessage, exitValidation)
+		}
+		opts.From, err = parseSinceFlag(c)
+		if err != nil {
+			return opts, err
+		}
+	}
+	to, err := parseDateFlag(c, "to")
+	if err != nil {
+		return opts, err
+	}
+	switch {
+	case to.IsZero():
+	case strings.Contains(c.String("to"), ":"):
+		// A date with a time is an exact bound, the transaction at that time included.
+		opts.To = to.Add(time.Nanosecond)
+	default:
+		_, opts.To = db.DayOf(to)
+	}
+	around, ok, err := parseAmountFlag(c, "around")
+	if err != nil {
+		return opts, err
+	} else if ok {
+		opts.Around = &around
+	}
+	opts.Tolerance, _, err = parseAmountFlag(c, "tolerance")
+	if err != nil {
+		return opts, err
+	}
+	return opts, nil
 }
 
-func main() {
-	app := cli.NewApp()
-	app.Name = "transaction"
-	app.Authors = []cli.Author{
-		{Name: "Lennart Espe", Email: "lennart@espe.tech"},
+// filterEntries returns the table entries of all transactions matching the options
+// and the matching transactions themselves.
+func filterEntries(database db.Database, opts db.FilterOptions) ([]tableEntry, []db.Transaction) {
+	var entries []tableEntry
+	database.Each(func(id int, transact db.Transaction) bool {
+		if opts.Match(transact) {
+			entries = append(entries, tableEntry{id, transact})
+		}
+		return true
+	})
+	return entries, db.Filter(database.Transactions, opts)
+}
+
+// parseAmountFlag reads an amount flag, ok is false if the flag was not given.
+func parseAmountFlag(c *cli.Context, name string) (value db.Value, ok bool, err error) {
+	if strings.TrimSpace(c.String(name)) == "" {
+		return db.ZeroValue, false, nil
 	}
-	app.Copyright = "(c) 2016 Lennart Espe"
-	app.Usage = "A housekeeping book in your terminal."
-	app.Version = "0.2"
-	app.Commands = []cli.Command{
+	value, err = db.ParseAmount(c.String(name))
+	if err != nil {
+		return db.ZeroValue, false, cli.NewExitError(fmt.Sprintf("invalid --%s amount '%s'", name, c.String(name)), exitValidation)
+	}
+	return value, true, nil
+}
+
+// isFutureDate checks if the calendar date of date lies after the one of now.
+// Both are read in their own location, parsed dates are UTC while the clock is local.
+func isFutureDate(date, now time.Time) bool {
+	_, tomorrow := db.DayOf(db.WallClock(now))
+	return !db.WallClock(date).Before(tomorrow)
+}
+
+// parseTransactionDate reads a date with an optional time of day,
+// dates without a time start at midnight.
+func parseTransactionDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, ":") {
+		return fmtdate.Parse(transactionDateTimeFormat, strings.Join(strings.Fields(s), " "))
+	}
+	return fmtdate.Parse(transactionDateFormat, s)
+}
+
+// parseDateFlag reads a date flag in the transaction date format.
+// A missing flag results in the zero time.
+func parseDateFlag(c *cli.Context, name string) (time.Time, error) {
+	if c.String(name) == "" {
+		return time.Time{}, nil
+	}
+	date, err := parseTransactionDate(c.String(name))
+	if err != nil {
+		return time.Time{}, cli.NewExitError(fmt.Sprintf("invalid --%s date '%s', expected %s", name, c.String(name), transactionDateFormat), exitValidation)
+	}
+	return date, nil
+}
+
+// parseSinceFlag resolves the relative --since flag against the current time.
+// A missing flag results in the zero time.
+func parseSinceFlag(c *cli.Context) (time.Time, error) {
+	if c.String("since") == "" {
+		return time.Time{}, nil
+	}
+	since, err := db.ParseRelativeDate(c.String("since"), time.Now())
+	if err != nil {
+		return time.Time{}, cli.NewExitError(fmt.Sprintf("invalid --since: %v (use e.g. 30d, 2w, 1m, 1y)", err), exitValidation)
+	}
+	return since, nil
+}
+
+func showAction(c *cli.Context) error {
+	ID, err := strconv.Atoi(c.Args().First())
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf(invalidTransactionIDMessage, c.Args().First()), exitValidation)
+	}
+	transact, err := db.Get(ID)
+	if os.IsNotExist(err) {
+		return databaseError(err)
+	} else if err != nil {
+		return cli.NewExitError(fmt.Sprintf(missingTransactionMessage, ID, err), exitNotFound)
+	}
+	fmt.Printf("%-10s #%d\n", "ID:", ID)
+	printDetails(transact)
+	return nil
+}
+
+// parseSplit reads a split given as category=amount.
+func parseSplit(s string) (string, db.Value, bool) {
+	i := strings.LastIndex(s, "=")
+	if i < 0 {
+		return "", db.ZeroValue, false
+	}
+	amount := db.Parse(s[i+1:])
+	return strings.TrimSpace(s[:i]), amount, amount.Larger(db.ZeroValue)
+}
+
+// printDetails prints every field of the transaction on its own line.
+func printDetails(transact db.Transaction) {
+	fmt.Printf("%-10s %s\n", "Name:", transact.Name)
+	fmt.Printf("%-10s %s\n", "Type:", transact.Type)
+	fmt.Printf("%-10s %s\n", "Amount:", transact.Amount.Format(transact.CurrencyOf()))
+	fmt.Printf("%-10s %s\n", "Date:", formatTime(transact.Date))
+	fmt.Printf("%-10s %s\n", "Category:", transact.Category)
+	for _, split := range transact.Splits {
+		fmt.Printf("%-10s %s %s\n", "Split:", split.Category, split.Amount.Format(transact.CurrencyOf()))
+	}
+	fmt.Printf("%-10s %s\n", "Tags:", strings.Join(transact.Tags, ", "))
+	fmt.Printf("%-10s %s\n", "Note:", transact.Note)
+	if transact.Reversal {
+		fmt.Printf("%-10s %s\n", "Reversal:", "yes")
+	}
+	if transact.FITID != "" {
+		fmt.Printf("%-10s %s\n", "FITID:", transact.FITID)
+	}
+}
+
+// pickAction returns an action printing the ID and details of the transaction chosen by pick.
+func pickAction(pick func([]db.Transaction) (int, db.Transaction, bool)) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		database, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		ID, transact, ok := pick(database.Transactions)
+		if !ok {
+			fmt.Printf(emptyDatabaseMessage, database.Name)
+			return nil
+		}
+		fmt.Printf("%-10s #%d\n", "ID:", ID)
+		printDetails(transact)
+		return nil
+	}
+}
+
+// periodAction returns an action listing the transactions of the period containing now,
+// as given by bounds, followed by their totals.
+func periodAction(label string, bounds func(time.Time) (time.Time, time.Time)) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		database, restore, err := openDisplayDatabase(c)
+		defer restore()
+		if err != nil {
+			return err
+		}
+		opts, err := filterOptions(c)
+		if err != nil {
+			return err
+		}
+		opts.From, opts.To = bounds(time.Now())
+		entries, filtered := filterEntries(database, opts)
+		header := fmt.Sprintf("%s (%s, %s - %s)", database.Name, label, formatTime(opts.From), formatTime(opts.To.Add(-time.Minute)))
+		printTransactionTable(os.Stdout, header, entries, c.Bool("reverse"))
+		if tableHeaders {
+			printTotals(os.Stdout, filtered)
+		}
+		return nil
+	}
+}
+
+func deleteAction(c *cli.Context) error {
+	ID, err := strconv.Atoi(c.Args().First())
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf(invalidTransactionIDMessage, c.Args().First()), exitValidation)
+	}
+	database, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	if !validIndex(ID, database.Size()-1) {
+		return cli.NewExitError(fmt.Sprintf(outOfRangeTransactionMessage, ID, database.Size()-1), exitNotFound)
+	}
+	transaction, err := database.Read(ID)
+	if err != nil {
+		return err
+	}
+	if c.Bool("dry-run") {
+		printTransactionTable(os.Stdout, dryRunHeader, []tableEntry{{ID, transaction}}, false)
+		change, _ := normalizedAmount(transaction)
+		fmt.Printf(dryRunMessage, change.Neg())
+		return nil
+	}
+	if !confirm(fmt.Sprint(transaction, wipeTransactionConfirmation), c.Bool("yes")) {
+		fmt.Println(abortedMessage)
+		return nil
+	}
+	err = db.Delete(ID)
+	if err != nil {
+		return databaseError(err)
+	}
+	fmt.Println(wipeTransactionSuccess)
+	return nil
+}
+
+func importAction(c *cli.Context) error {
+	file, err := os.Open(c.Args().First())
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	database, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	currency := database.BookCurrency()
+	if c.String("assume-currency") != "" {
+		currency, err = db.LookupCurrency(c.String("assume-currency"))
+		if err != nil {
+			return exitError(err)
+		}
+	}
+	var (
+		transactions []db.Transaction
+		rejected     int
+	)
+	switch c.String("format") {
+	case importFormatOFX:
+		transactions, err = db.ParseOFX(file)
+	case importFormatCSV:
+		transactions, rejected, err = db.ParseCSV(file, currency)
+	default:
+		return cli.NewExitError(fmt.Sprintf("unsupported import format '%s'", c.String("format")), exitValidation)
+	}
+	if err != nil {
+		return exitError(err)
+	}
+	if foreign := db.ForeignCurrencies(transactions, currency); c.String("assume-currency") == "" && len(foreign) > 0 {
+		return cli.NewExitError(fmt.Sprintf(importCurrencyMismatchMessage, strings.Join(foreign, ", "), currency.Name), exitValidation)
+	}
+	db.AssumeCurrency(transactions, currency)
+	result := db.Import(&database, transactions)
+	result.Read += rejected
+	result.Rejected += rejected
+	err = db.Write(database)
+	if err != nil {
+		return databaseError(err)
+	}
+	fmt.Printf(importSuccessMessage, result.Read, result.Imported, result.Skipped, result.Rejected, database.Size())
+	return nil
+}
+
+// parseBatchLine reads a line of name,type,amount and an optional date.
+// Fields are comma-separated, quote amounts with a decimal comma like "12,50".
+func parseBatchLine(line string) (db.Transaction, error) {
+	reader := csv.NewReader(strings.NewReader(line))
+	reader.FieldsPerRecord = -1
+	fields, err := reader.Read()
+	if err != nil {
+		return db.Transaction{}, err
+	}
+	if len(fields) < 3 || len(fields) > 4 {
+		return db.Transaction{}, fmt.Errorf("expected name,type,amount[,date] but got %d fields", len(fields))
+	}
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	amount := db.Parse(fields[2])
+	if amount == db.ZeroValue {
+		return db.Transaction{}, fmt.Errorf("invalid amount '%s'", fields[2])
+	}
+	date := time.Now()
+	if len(fields) == 4 && fields[3] != "" {
+		date, err = parseTransactionDate(fields[3])
+		if err != nil {
+			return db.Transaction{}, fmt.Errorf("invalid date '%s', expected %s or %s", fields[3], transactionDateFormat, transactionDateTimeFormat)
+		}
+	}
+	action := parseAction(fields[1])
+	if action == "" {
+		action = db.Action(fields[1])
+	}
+	transact := db.NewTransaction(fields[0], action, amount, date)
+	return transact, transact.Validate()
+}
+
+func batchAction(c *cli.Context) error {
+	file, err := os.Open(c.Args().First())
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	var (
+		transactions []db.Transaction
+		lines        int
+		invalid      int
+	)
+	scanner := bufio.NewScanner(file)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines++
+		transact, err := parseBatchLine(line)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, batchLineMessage, number, err)
+			invalid++
+			continue
+		}
+		transactions = append(transactions, transact)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if invalid > 0 {
+		return cli.NewExitError(fmt.Sprintf(batchFailedMessage, invalid, lines), exitValidation)
+	}
+	database, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	for _, transact := range transactions {
+		database.Store(transact)
+	}
+	err = db.Write(database)
+	if err != nil {
+		return databaseError(err)
+	}
+	fmt.Printf(batchSuccessMessage, len(transactions), database.Size())
+	return nil
+}
+
+func mergeAction(c *cli.Context) error {
+	path := c.Args().First()
+	if path == "" {
+		return cli.NewExitError("missing database to merge", exitValidation)
+	}
+	other, err := db.OpenFile(path)
+	if err != nil {
+		return exitError(err)
+	}
+	database, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	added := db.Merge(&database, other, c.Bool("dedupe"))
+	err = db.Write(database)
+	if err != nil {
+		return databaseError(err)
+	}
+	fmt.Printf(mergeSuccessMessage, added, other.Size(), other.Name, database.Size())
+	return nil
+}
+
+func reconcileAction(c *cli.Context) error {
+	file, err := os.Open(c.Args().First())
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	database, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	statement, rejected, err := db.ParseCSV(file, database.BookCurrency())
+	if err != nil {
+		return exitError(err)
+	}
+	if rejected > 0 {
+		fmt.Printf(reconcileRejectedMessage, rejected)
+	}
+	db.ReconcileWindow = time.Duration(c.Int("days")) * 24 * time.Hour
+	missingInBook, missingInStmt := db.Reconcile(database, statement)
+	if len(missingInBook) == 0 && len(missingInStmt) == 0 {
+		fmt.Printf(reconcileSuccessMessage, database.Name, len(statement))
+		return nil
+	}
+	for _, section := range []struct {
+		header       string
+		transactions []db.Transaction
+	}{
+		{fmt.Sprintf("Missing in %s (%d)", database.Name, len(missingInBook)), missingInBook},
+		{fmt.Sprintf("Missing in the statement (%d)", len(missingInStmt)), missingInStmt},
+	} {
+		table := newReportTable(os.Stdout, section.header, "Date", "Name", "Type", "Amount")
+		for _, transact := range section.transactions {
+			amount := transact.Amount.Format(transact.CurrencyOf())
+			table.row(fmt.Sprintf("%s %s :: %-8s %s", limitString(formatTime(transact.Date), defaultDateWidth), limitString(transact.Name, defaultNameWidth), transact.Type, padLeft(amount, minAmountWidth)),
+				formatTime(transact.Date), transact.Name, string(transact.Type), amount)
+		}
+	}
+	return cli.NewExitError("", exitGeneric)
+}
+
+func budgetAction(c *cli.Context) error {
+	database, restore, err := openDisplayDatabase(c)
+	defer restore()
+	if err != nil {
+		return err
+	}
+	database, err = convertDatabase(database)
+	if err != nil {
+		return err
+	}
+	limits, err := db.OpenBudget()
+	if err != nil {
+		return err
+	}
+	month := time.Now()
+	if c.String("month") != "" {
+		month, err = fmtdate.Parse(budgetMonthFormat, c.String("month"))
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("invalid month %q, expected %s", c.String("month"), budgetMonthFormat), exitValidation)
+		}
+	}
+	header := fmt.Sprintf("%s (budget %02d.%04d)", database.Name, month.Month(), month.Year())
+	table := newReportTable(os.Stdout, header, "Category", "Spent", "Limit", "Remaining", "Status")
+	for _, line := range db.BudgetStatus(database, limits, month) {
+		status := ""
+		if line.Over() {
+			status = budgetOverBudgetLabel
+		}
+		table.row(fmt.Sprintf("%s %12s / %12s  %12s  %s", limitString(line.Category, 20), line.Spent, line.Limit, line.Remaining, status),
+			line.Category, line.Spent.String(), line.Limit.String(), line.Remaining.String(), status)
+	}
+	return nil
+}
+
+func budgetSetAction(c *cli.Context) error {
+	category := c.Args().Get(0)
+	if category == "" {
+		return cli.NewExitError("missing category", exitValidation)
+	}
+	limit, err := db.ParseAmount(c.Args().Get(1))
+	if err != nil || limit < db.ZeroValue {
+		return cli.NewExitError(fmt.Sprintf(budgetInvalidMessage, c.Args().Get(1)), exitValidation)
+	}
+	limits, err := db.OpenBudget()
+	if err != nil {
+		return err
+	}
+	limits[category] = limit
+	err = db.WriteBudget(limits)
+	if err != nil {
+		return err
+	}
+	fmt.Printf(budgetSuccessMessage, category, limit)
+	return nil
+}
+
+func tagsAction(c *cli.Context) error {
+	database, restore, err := openDisplayDatabase(c)
+	defer restore()
+	if err != nil {
+		return err
+	}
+	database, err = convertDatabase(database)
+	if err != nil {
+		return err
+	}
+	stats, header := db.DistinctNames(database.Transactions), database.Name+" (names)"
+	if c.Bool("category") {
+		stats, header = db.DistinctCategories(database.Transactions), database.Name+" (categories)"
+	}
+	var tags []string
+	for tag := range stats {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if stats[tags[i]].Total == stats[tags[j]].Total {
+			return tags[i] < tags[j]
+		}
+		return stats[tags[i]].Total.Larger(stats[tags[j]].Total)
+	})
+	table := newReportTable(os.Stdout, header, "Name", "Count", "Total")
+	for _, tag := range tags {
+		name := tag
+		if name == "" {
+			name = tagsNoCategory
+		}
+		table.row(fmt.Sprintf("%s %6dx %s", limitString(name, 20), stats[tag].Count, padLeft(stats[tag].Total.String(), minAmountWidth)),
+			name, strconv.Itoa(stats[tag].Count), stats[tag].Total.String())
+	}
+	return nil
+}
+
+func tagsStatsAction(c *cli.Context) error {
+	database, restore, err := openDisplayDatabase(c)
+	defer restore()
+	if err != nil {
+		return err
+	}
+	database, err = convertDatabase(database)
+	if err != nil {
+		return err
+	}
+	var (
+		categories []db.CategorySummary
+		spent      []db.Value
+	)
+	for _, category := range db.SummarizeByCategory(database) {
+		if category.Withdrawals != db.ZeroValue {
+			categories = append(categories, category)
+			spent = append(spent, category.Withdrawals)
+		}
+	}
+	table := newReportTable(os.Stdout, database.Name+" (share of withdrawals)", "Category", "Withdrawals", "Share")
+	for i, share := range db.Shares(spent) {
+		name := categories[i].Category
+		if name == "" {
+			name = tagsNoCategory
+		}
+		percent := fmt.Sprintf("%.1f%%", float64(share)/10)
+		table.row(fmt.Sprintf("%s %s %6s", limitString(name, 20), padLeft(categories[i].Withdrawals.String(), minAmountWidth), percent),
+			name, categories[i].Withdrawals.String(), percent)
+	}
+	return nil
+}
+
+func transferAction(c *cli.Context) error {
+	src, dst := c.Args().Get(0), c.Args().Get(1)
+	if src == "" || dst == "" {
+		return cli.NewExitError("missing source or destination database", exitValidation)
+	}
+	source, err := db.OpenFile(src)
+	if err != nil {
+		return databaseError(err)
+	}
+	// The amount and the exchange rates are given in the book currency of the source.
+	previous := db.DefaultCurrency
+	defer func() { db.DefaultCurrency = previous }()
+	db.DefaultCurrency = source.BookCurrency()
+	if err := useRates(c); err != nil {
+		return err
+	}
+	amount, _, err := parseAmountFlag(c, "amount")
+	if err != nil {
+		return err
+	}
+	date, err := parseDateFlag(c, "date")
+	if err != nil {
+		return err
+	}
+	if date.IsZero() {
+		date = time.Now()
+	}
+	err = db.TransferFiles(src, dst, amount, c.String("name"), date)
+	if err != nil {
+		return err
+	}
+	fmt.Printf(transferSuccessMessage, amount, src, dst)
+	return nil
+}
+
+func verifyAction(c *cli.Context) error {
+	database, err := openDatabase()
+	if err != nil {
+		return exitError(err)
+	}
+	problems := db.Verify(database)
+	if len(problems) > 0 {
+		for _, problem := range problems {
+			fmt.Println(problem)
+		}
+		return cli.NewExitError(fmt.Sprintf(verifyFailureMessage, len(problems)), exitValidation)
+	}
+	fmt.Printf(verifySuccessMessage, database.Name, database.Size())
+	return nil
+}
+
+func repairAction(c *cli.Context) error {
+	database, err := openDatabase()
+	if err != nil {
+		return exitError(err)
+	}
+	for _, problem := range db.Verify(database) {
+		fmt.Println(problem)
+	}
+	removed := db.Repair(&database)
+	if removed == 0 {
+		fmt.Printf(repairNothingMessage, database.Name)
+		return nil
+	}
+	if !confirm(fmt.Sprintf(repairConfirmation, removed), c.Bool("yes")) {
+		fmt.Println(abortedMessage)
+		return nil
+	}
+	err = db.Write(database)
+	if err != nil {
+		return databaseError(err)
+	}
+	fmt.Printf(repairSuccessMessage, removed, database.Size())
+	return nil
+}
+
+func backupAction(c *cli.Context) error {
+	dir := c.Args().First()
+	if dir == "" {
+		dir = "."
+	}
+	dest := filepath.Join(dir, fmt.Sprintf(backupFileFormat, time.Now().Format(backupTimeFormat)))
+	err := db.Backup(dest)
+	if err != nil {
+		return databaseError(err)
+	}
+	fmt.Printf(backupSuccessMessage, dest)
+	return nil
+}
+
+func restoreAction(c *cli.Context) error {
+	src := c.Args().First()
+	if src == "" {
+		return cli.NewExitError("missing backup path", exitValidation)
+	}
+	if db.Exists() && !confirm(restoreConfirmation, c.Bool("force") || c.Bool("yes")) {
+		fmt.Println(abortedMessage)
+		return nil
+	}
+	err := db.RestoreFrom(src)
+	if err != nil {
+		return exitError(err)
+	}
+	fmt.Printf(restoreSuccessMessage, src)
+	return nil
+}
+
+func summaryAction(c *cli.Context) error {
+	database, restore, err := openDisplayDatabase(c)
+	defer restore()
+	if err != nil {
+		return err
+	}
+	database, err = convertDatabase(database)
+	if err != nil {
+		return err
+	}
+	var (
+		periods   []string
+		summaries []db.Summary
+	)
+	switch c.String("period") {
+	case summaryPeriodMonth:
+		for _, month := range db.SummarizeByMonth(database) {
+			periods = append(periods, fmt.Sprintf("%s %04d", monthName(month.Month, displayLocale), month.Year))
+			summaries = append(summaries, month.Summary)
+		}
+	case summaryPeriodWeek:
+		for _, week := range db.SummarizeByWeek(database) {
+			periods = append(periods, fmt.Sprintf("Week %02d %04d", week.Week, week.Year))
+			summaries = append(summaries, week.Summary)
+		}
+	default:
+		return cli.NewExitError(fmt.Sprintf("unknown summary period '%s'", c.String("period")), exitValidation)
+	}
+	out, err := openOutput(c)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if _, raw := rawAmount(c, db.ZeroValue); raw {
+		// Only the numbers of each period, one period per line.
+		for _, summary := range summaries {
+			deposits, _ := rawAmount(c, summary.Deposits)
+			withdrawals, _ := rawAmount(c, summary.Withdrawals)
+			net, _ := rawAmount(c, summary.Net)
+			fmt.Fprintf(out, "%d\t%s\t%s\t%s\n", summary.Count, deposits, withdrawals, net)
+		}
+		return nil
+	}
+	table := newReportTable(out, fmt.Sprintf("%s (per %s)", database.Name, c.String("period")), "Period", "Count", "Deposits", "Withdrawals", "Net")
+	for i, summary := range summaries {
+		table.row(fmt.Sprintf("%s %4dx %s %s %s", limitString(periods[i], 20), summary.Count, padLeft(summary.Deposits.String(), minAmountWidth), padLeft(summary.Withdrawals.String(), minAmountWidth), padLeft(summary.Net.String(), minAmountWidth)),
+			periods[i], strconv.Itoa(summary.Count), summary.Deposits.String(), summary.Withdrawals.String(), summary.Net.String())
+	}
+	return nil
+}
+
+func clearAction(c *cli.Context) error {
+	database, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	if !confirm(fmt.Sprintf(clearConfirmation, database.Size()), c.Bool("force") || c.Bool("yes")) {
+		fmt.Println(abortedMessage)
+		return nil
+	}
+	if c.Bool("archive") {
+		err = backupAction(c)
+		if err != nil {
+			return err
+		}
+	}
+	db.Clear(&database)
+	err = db.Write(database)
+	if err != nil {
+		return databaseError(err)
+	}
+	fmt.Printf(clearSuccessMessage, database.Name)
+	return nil
+}
+
+// confirm asks a yes / no question, anything but yes counts as no.
+// If skip is set, the question is not asked and counts as yes.
+func confirm(prompt string, skip bool) bool {
+	if skip {
+		return true
+	}
+	fmt.Print(prompt)
+	answer, _ := getInput()
+	answer = strings.ToLower(answer)
+	return answer == confirmYes || answer == confirmYesLong
+}
+
+// promptDefault asks for a field, keeping the current value on empty input.
+func promptDefault(field, current string) string {
+	field = strings.TrimSuffix(field, ": ")
+	if i := strings.Index(field, " ["); i >= 0 {
+		field = field[:i]
+	}
+	fmt.Printf(duplicateFieldFormat, field, current)
+	input, _ := getInput()
+	if input == "" {
+		return current
+	}
+	return input
+}
+
+func duplicateAction(c *cli.Context) error {
+	ID, err := strconv.Atoi(c.Args().First())
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf(invalidTransactionIDMessage, c.Args().First()), exitValidation)
+	}
+	database, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	original, err := database.Read(ID)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf(missingTransactionMessage, ID, err), exitNotFound)
+	}
+	clone := original
+	clone.FITID, clone.TransferID = "", ""
+	clone.Name = promptDefault(transactionNameField, original.Name)
+	dateStr := promptDefault(transactionDateField, fmtdate.Format(transactionDateFormat, time.Now()))
+	clone.Date, err = parseTransactionDate(dateStr)
+	if err != nil {
+		clone.Date = time.Now()
+	}
+	clone.Type = parseAction(promptDefault(transactionTypeField, string(original.Type)))
+	clone.Amount = db.Parse(promptDefault(transactionAmountField, original.Amount.Format(original.CurrencyOf()))).Abs()
+	clone.Category = promptDefault(transactionCategoryField, original.Category)
+	clone.Note = promptDefault(transactionNoteField, original.Note)
+	if err := clone.Validate(); err != nil {
+		return exitError(err)
+	}
+	database.Store(clone)
+	err = db.Write(database)
+	if err != nil {
+		return databaseError(err)
+	}
+	fmt.Printf(duplicateSuccessMessage, ID, database.Size()-1)
+	return nil
+}
+
+func countAction(c *cli.Context) error {
+	database, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	opts, err := filterOptions(c)
+	if err != nil {
+		return err
+	}
+	count := db.Count(database.Transactions, opts.Match)
+	if c.Bool("quiet") {
+		fmt.Println(count)
+		return nil
+	}
+	fmt.Printf(countMessage, database.Name, count)
+	return nil
+}
+
+func exportAction(c *cli.Context) error {
+	format := c.String("format")
+	if format != exportFormatQIF && format != exportFormatCSV {
+		return cli.NewExitError(fmt.Sprintf("unsupported export format '%s'", format), exitValidation)
+	}
+	locale := c.String("csv-locale")
+	if locale == "" && c.GlobalIsSet("locale") {
+		locale = c.GlobalString("locale")
+	} else if locale == "" {
+		locale = "en"
+	}
+	csvFormat, ok := csvLocales[strings.ToLower(locale)]
+	if !ok {
+		return cli.NewExitError(fmt.Sprintf(unknownLocaleMessage, locale), exitValidation)
+	}
+	database, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	out, err := openOutput(c)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if format == exportFormatCSV {
+		return db.ExportCSV(out, database, csvFormat)
+	}
+	return db.ExportQIF(out, database)
+}
+
+// csvLocales maps the --csv-locale of CSV exports to their delimiter and decimal mark.
+var csvLocales = map[string]db.CSVFormat{
+	"en": db.CSVEnglish,
+	"de": db.CSVGerman,
+}
+
+func renameAction(c *cli.Context) error {
+	database, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	previous := database.Name
+	err = db.Rename(&database, strings.Join(c.Args(), " "))
+	if err != nil {
+		return exitError(err)
+	}
+	err = db.Write(database)
+	if err != nil {
+		return databaseError(err)
+	}
+	fmt.Printf(renameSuccessMessage, previous, database.Name)
+	return nil
+}
+
+// bulkRenameAction returns an action renaming a field of all matching transactions.
+func bulkRenameAction(rename func(*db.Database, string, string) int) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		old, new := c.Args().Get(0), c.Args().Get(1)
+		if c.NArg() != 2 || strings.TrimSpace(new) == "" {
+			return cli.NewExitError("expected the old and the new value", exitValidation)
+		}
+		database, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		changed := rename(&database, old, new)
+		if changed > 0 {
+			err = db.Write(database)
+			if err != nil {
+				return databaseError(err)
+			}
+		}
+		fmt.Printf(bulkRenameMessage, changed, old, new)
+		return nil
+	}
+}
+
+func infoAction(c *cli.Context) error {
+	database, restore, err := openDisplayDatabase(c)
+	defer restore()
+	if err != nil {
+		return err
+	}
+	path := db.Path()
+	if path == db.StdioPath {
+		path = infoStdinPath
+	}
+	fmt.Printf(infoFieldFormat, "Name:", database.Name)
+	fmt.Printf(infoFieldFormat, "Path:", path)
+	fmt.Printf(infoFieldFormat, "Transactions:", database.Size())
+	fmt.Printf(infoFieldFormat, "Currency:", database.BookCurrency().Name)
+	balance := unconvertibleAmount
+	if converted, err := db.ConvertDatabase(database, db.DefaultCurrency); err == nil {
+		balance = converted.Balance().String()
+	}
+	fmt.Printf(infoFieldFormat, "Balance:", balance)
+	if _, oldest, ok := db.Oldest(database.Transactions); ok {
+		_, latest, _ := db.Latest(database.Transactions)
+		fmt.Printf(infoFieldFormat, "Date range:", formatTime(oldest.Date)+" - "+formatTime(latest.Date))
+	}
+	var categories []string
+	for category := range db.DistinctCategories(database.Transactions) {
+		if category != "" {
+			categories = append(categories, category)
+		}
+	}
+	sort.Strings(categories)
+	summary := strconv.Itoa(len(categories))
+	if len(categories) > 0 {
+		summary += " (" + strings.Join(categories, ", ") + ")"
+	}
+	fmt.Printf(infoFieldFormat, "Categories:", summary)
+	if path == infoStdinPath {
+		return nil
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	fmt.Printf(infoFieldFormat, "File size:", fmt.Sprintf("%d bytes", stat.Size()))
+	fmt.Printf(infoFieldFormat, "Modified:", formatTime(stat.ModTime()))
+	return nil
+}
+
+// rawAmount formats the value as requested by the --raw or --decimal flag,
+// raw is false if neither was given.
+func rawAmount(c *cli.Context, v db.Value) (amount string, raw bool) {
+	switch {
+	case c.Bool("decimal"):
+		return v.Decimal(), true
+	case c.Bool("raw"):
+		return strconv.Itoa(int(v)), true
+	}
+	return "", false
+}
+
+func balanceAction(c *cli.Context) error {
+	database, restore, err := openDisplayDatabase(c)
+	defer restore()
+	if err != nil {
+		return err
+	}
+	database, err = convertDatabase(database)
+	if err != nil {
+		return err
+	}
+	amount, raw := rawAmount(c, database.Balance())
+	switch {
+	case raw && c.Bool("per-day"):
+		return cli.NewExitError(rawPerDayMessage, exitValidation)
+	case raw:
+		fmt.Println(amount)
+		return nil
+	case !c.Bool("per-day"):
+		fmt.Printf(balanceMessage, database.Name, database.Balance())
+		return nil
+	}
+	from, err := parseDateFlag(c, "from")
+	if err != nil {
+		return err
+	}
+	to, err := parseDateFlag(c, "to")
+	if err != nil {
+		return err
+	}
+	if from.IsZero() {
+		from = time.Now()
+		for _, transact := range database.Transactions {
+			if transact.Date.Before(from) {
+				from = transact.Date
+			}
+		}
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+	series := db.BalanceSeries(database.Transactions, from, to)
+	switch c.String("format") {
+	case balanceFormatCSV:
+		writer := csv.NewWriter(os.Stdout)
+		writer.Write([]string{"date", "balance"})
+		for _, day := range series {
+			writer.Write([]string{day.Date.Format(balanceSeriesLayout), day.Balance.Decimal()})
+		}
+		writer.Flush()
+		return writer.Error()
+	case balanceFormatJSON:
+		return json.NewEncoder(os.Stdout).Encode(series)
+	}
+	return cli.NewExitError(fmt.Sprintf("unsupported balance format '%s'", c.String("format")), exitValidation)
+}
+
+// localMonthNames holds the month names of the supported languages besides English.
+var localMonthNames = map[string][12]string{
+	"de": {"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+}
+
+// filterDirections maps the --direction flag to the transaction types.
+var filterDirections = map[string]db.Action{
+	"in":  db.Deposit,
+	"out": db.Withdraw,
+}
+
+// roundingModes maps the --rounding flag to the rounding modes.
+var roundingModes = map[string]db.RoundingMode{
+	"half-up":   db.RoundHalfUp,
+	"half-even": db.RoundHalfEven,
+	"truncate":  db.RoundTruncate,
+}
+
+// reportGroupings maps the --group-by values to their key functions.
+var reportGroupings = map[string]func(db.Transaction) string{
+	"name":     func(t db.Transaction) string { return t.Name },
+	"category": func(t db.Transaction) string { return t.Category },
+	"month":    func(t db.Transaction) string { return t.Date.Format(reportMonthLayout) },
+	"type":     func(t db.Transaction) string { return string(t.Type) },
+}
+
+// reportColumns maps the --group-by values to the header of their key column.
+var reportColumns = map[string]string{
+	"name":     "Name",
+	"category": "Category",
+	"month":    "Month",
+	"type":     "Type",
+}
+
+func reportAction(c *cli.Context) error {
+	keyFn, ok := reportGroupings[c.String("group-by")]
+	if !ok {
+		return cli.NewExitError(fmt.Sprintf("unknown grouping '%s' (use name, category, month or type)", c.String("group-by")), exitValidation)
+	}
+	since, err := parseSinceFlag(c)
+	if err != nil {
+		return err
+	}
+	database, restore, err := openDisplayDatabase(c)
+	defer restore()
+	if err != nil {
+		return err
+	}
+	database, err = convertDatabase(database)
+	if err != nil {
+		return err
+	}
+	var period []db.Transaction
+	for _, transact := range database.Transactions {
+		if !transact.Date.Before(since) {
+			period = append(period, transact)
+		}
+	}
+	groups := db.GroupBy(db.SplitParts(period), keyFn)
+	if c.Bool("include-empty") {
+		// Known keys are those of the whole history and the budgeted categories.
+		for key := range db.GroupBy(db.SplitParts(database.Transactions), keyFn) {
+			if _, ok := groups[key]; !ok {
+				groups[key] = db.ZeroValue
+			}
+		}
+		if c.String("group-by") == "category" {
+			limits, err := db.OpenBudget()
+			if err != nil {
+				return err
+			}
+			for category := range limits {
+				if _, ok := groups[category]; !ok {
+					groups[category] = db.ZeroValue
+				}
+			}
+		}
+	}
+	var keys []string
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	out, err := openOutput(c)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	table := newReportTable(out, fmt.Sprintf("%s (by %s)", database.Name, c.String("group-by")), reportColumns[c.String("group-by")], "Net")
+	for _, key := range keys {
+		label := key
+		if label == "" {
+			label = reportEmptyKey
+		}
+		table.row(fmt.Sprintf("%s %s", limitString(label, 20), padLeft(groups[key].String(), minAmountWidth)), label, groups[key].String())
+	}
+	return nil
+}
+
+func averageAction(c *cli.Context) error {
+	database, restore, err := openDisplayDatabase(c)
+	defer restore()
+	if err != nil {
+		return err
+	}
+	database, err = convertDatabase(database)
+	if err != nil {
+		return err
+	}
+	keyFn := func(db.Transaction) string { return "" }
+	if c.Bool("by-category") {
+		keyFn = reportGroupings["category"]
+	}
+	averages, months := db.AverageByMonth(database, keyFn, c.Bool("prorate"))
+	if !c.Bool("by-category") {
+		fmt.Printf(averageMessage, database.Name, averages[""], months)
+		return nil
+	}
+	var keys []string
+	for key := range averages {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	table := newReportTable(os.Stdout, fmt.Sprintf("%s (monthly average over %.1f months)", database.Name, months), "Category", "Average")
+	for _, key := range keys {
+		label := key
+		if label == "" {
+			label = reportEmptyKey
+		}
+		table.row(fmt.Sprintf("%s %s", limitString(label, 20), padLeft(averages[key].String(), minAmountWidth)), label, averages[key].String())
+	}
+	return nil
+}
+
+func topAction(c *cli.Context) error {
+	opts, err := filterOptions(c)
+	if err != nil {
+		return err
+	}
+	database, restore, err := openDisplayDatabase(c)
+	defer restore()
+	if err != nil {
+		return err
+	}
+	database, err = convertDatabase(database)
+	if err != nil {
+		return err
+	}
+	top := db.Top(database.Transactions, c.Int("n"), opts.Match)
+	table := newReportTable(os.Stdout, fmt.Sprintf("%s (top %d)", database.Name, len(top)), "Rank", "Name", "Type", "Amount", "Date")
+	for i, transact := range top {
+		amount := transact.Amount.Format(transact.CurrencyOf())
+		table.row(fmt.Sprintf("%3d. %s :: %-8s %s  %s", i+1, limitString(transact.Name, 20), transact.Type, padLeft(amount, minAmountWidth), formatTime(transact.Date)),
+			strconv.Itoa(i+1), transact.Name, string(transact.Type), amount, formatTime(transact.Date))
+	}
+	return nil
+}
+
+func compactAction(c *cli.Context) error {
+	err := db.Compact()
+	if err != nil {
+		return databaseError(err)
+	}
+	fmt.Printf(compactSuccessMessage, db.Path())
+	return nil
+}
+
+func editAction(c *cli.Context) error {
+	tmp, err := ioutil.TempFile("", editTempPattern)
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+	if err := db.Backup(tmp.Name()); err != nil {
+		return databaseError(err)
+	}
+	before, err := ioutil.ReadFile(tmp.Name())
+	if err != nil {
+		return err
+	}
+	editor := strings.Fields(os.Getenv("EDITOR"))
+	if len(editor) == 0 {
+		editor = []string{defaultEditor}
+	}
+	cmd := exec.Command(editor[0], append(editor[1:], tmp.Name())...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return cli.NewExitError(err.Error(), exitGeneric)
+	}
+	after, err := ioutil.ReadFile(tmp.Name())
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(before, after) {
+		fmt.Println(editUnchangedMessage)
+		return nil
+	}
+	database, err := db.OpenFile(tmp.Name())
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("%s\n%v", editInvalidMessage, err), exitValidation)
+	}
+	if problems := db.Verify(database); len(problems) > 0 {
+		for _, problem := range problems {
+			fmt.Println(problem)
+		}
+		return cli.NewExitError(editInvalidMessage, exitValidation)
+	}
+	if err := db.Write(database); err != nil {
+		return databaseError(err)
+	}
+	fmt.Printf(editSuccessMessage, database.Size())
+	return nil
+}
+
+func main() {
+	if err := newApp().Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// newApp builds the command line interface with all commands and global flags.
+func newApp() *cli.App {
+	app := cli.NewApp()
+	app.Name = "transaction"
+	app.Authors = []cli.Author{
+		{Name: "Lennart Espe", Email: "lennart@espe.tech"},
+	}
+	app.Copyright = "(c) 2016 Lennart Espe"
+	app.Usage = "A housekeeping book in your terminal."
+	app.Version = "0.2"
+	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:   "db",
+			Value:  "",
+			Usage:  "Path of the database file, - reads it from stdin (default ~/.trdb)",
+			EnvVar: "TRANSACTION_DB",
+		},
+		cli.IntFlag{
+			Name:  "width, w",
+			Value: 0,
+			Usage: "Width of tables, defaults to $COLUMNS",
+		},
+		cli.StringFlag{
+			Name:  "rounding",
+			Value: "half-up",
+			Usage: "Rounding of extra minor digits (half-up, half-even or truncate)",
+		},
+		cli.StringFlag{
+			Name:  "style",
+			Value: tableStyleASCII,
+			Usage: "Style of tables (ascii, markdown, plain or compact)",
+		},
+		cli.BoolFlag{
+			Name:  "compact",
+			Usage: "Print one short line per transaction, same as --style compact",
+		},
+		cli.BoolFlag{
+			Name:  "signed",
+			Usage: "Prefix amounts in tables with + for deposits and - for withdrawals",
+		},
+		cli.BoolFlag{
+			Name:  "no-header",
+			Usage: "Print only the rows of transaction tables, without header and total",
+		},
+		cli.BoolFlag{
+			Name:  "indent",
+			Usage: "Write the database as indented JSON, which is easier to read and diff (--indent=false writes compact JSON), databases keep their layout otherwise",
+		},
+		cli.BoolFlag{
+			Name:  "no-color",
+			Usage: "Disable colored output (also disabled by $NO_COLOR)",
+		},
+		cli.StringFlag{
+			Name:  "locale",
+			Value: "",
+			Usage: "Language of month names (en or de), defaults to $LANG",
+		},
+		cli.StringFlag{
+			Name:  "date-format",
+			Value: "",
+			Usage: "Format of displayed dates (iso, us or a custom pattern like DD.MM.YYYY)",
+		},
+	}
+	app.CommandNotFound = func(c *cli.Context, command string) {
+		fmt.Fprintf(os.Stderr, unknownCommandMessage, command)
+		os.Exit(exitGeneric)
+	}
+	app.Before = func(c *cli.Context) error {
+		db.SetPath(c.String("db"))
+		setTimeFormat(c.String("date-format"))
+		displayLocale = strings.ToLower(c.String("locale"))
+		if displayLocale == "" {
+			displayLocale = localeFromEnv()
+		} else if _, ok := localMonthNames[displayLocale]; !ok && displayLocale != "en" {
+			return cli.NewExitError(fmt.Sprintf(unknownLocaleMessage, c.String("locale")), exitValidation)
+		}
+		if !validTableStyle(c.String("style")) {
+			return cli.NewExitError(fmt.Sprintf(unknownStyleMessage, c.String("style")), exitValidation)
+		}
+		tableStyle = c.String("style")
+		if c.Bool("compact") {
+			tableStyle = tableStyleCompact
+		}
+		tableHeaders = !c.Bool("no-header")
+		signedAmounts = c.Bool("signed")
+		mode, ok := roundingModes[c.String("rounding")]
+		if !ok {
+			return cli.NewExitError(fmt.Sprintf(unknownRoundingMessage, c.String("rounding")), exitValidation)
+		}
+		db.SetRounding(mode)
+		if c.GlobalIsSet("indent") {
+			db.SetIndent(c.Bool("indent"))
+		}
+		colorEnabled = !c.Bool("no-color") && os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout)
+		tableWidth = terminalWidth()
+		if c.Int("width") > 0 {
+			tableWidth = c.Int("width")
+		}
+		return nil
+	}
+	app.Commands = []cli.Command{
 		{
 			Name:   "init",
 			Usage:  "Initialize the database",
 			Action: initAction,
+			Before: requireWritable,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "force, f",
+					Usage: "Disable any warnings",
+				},
+				cli.StringFlag{
+					Name:  "currency",
+					Value: "",
+					Usage: "Currency the book is kept in (euro, dollar, ...), defaults to euro",
+				},
+				yesFlag,
+			},
+		},
+		{
+			Name:   "store",
+			Usage:  "Store a new transaction",
+			Action: storeAction,
+			Before: requireWritable,
 			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "name, n",
+					Value: "",
+					Usage: "Name of the transaction",
+				},
+				cli.StringFlag{
+					Name:  "type, t",
+					Value: "",
+					Usage: "Type of the transaction (withdraw or deposit)",
+				},
+				cli.StringFlag{
+					Name:  "amount, a",
+					Value: "",
+					Usage: "Amount of the transaction (in standard currency format)",
+				},
+				cli.StringFlag{
+					Name:  "date, d",
+					Value: "",
+					Usage: "Date of the transaction (D.M.YYYY with an optional hh:mm), defaults to now",
+				},
+				cli.StringFlag{
+					Name:  "category",
+					Value: "",
+					Usage: "Category of the transaction",
+				},
+				cli.StringSliceFlag{
+					Name:  "split",
+					Usage: "Allocate part of the amount to a category (category=amount), can be repeated",
+				},
+				cli.StringFlag{
+					Name:  "tags",
+					Value: "",
+					Usage: "Comma-separated tags of the transaction",
+				},
+				cli.StringFlag{
+					Name:  "note",
+					Value: "",
+					Usage: "Note attached to the transaction",
+				},
+				cli.StringFlag{
+					Name:  "currency",
+					Value: "",
+					Usage: "Currency of the transaction, defaults to the book currency",
+				},
+				cli.BoolFlag{
+					Name:  "reversal",
+					Usage: "Correct an earlier transaction, a reversed deposit subtracts and a reversed withdrawal adds",
+				},
+				cli.BoolFlag{
+					Name:  "no-future",
+					Usage: "Reject dates after today",
+				},
+				cli.BoolFlag{
+					Name:  "check-dupes",
+					Usage: "Ask before storing a duplicate transaction",
+				},
 				cli.BoolFlag{
 					Name:  "force, f",
 					Usage: "Disable any warnings",
 				},
 			},
 		},
-		{
-			Name:   "store",
-			Usage:  "Store a new transaction",
-			Action: storeAction,
-		},
 		{
 			Name:   "list",
 			Usage:  "List all transactions",
@@ -266,14 +2332,57 @@ func main() {
 				cli.IntFlag{
 					Name:  "limit, l",
 					Value: 10,
-					Usage: "Amount of entries shown",
+					Usage: "Amount of entries shown (0 shows all)",
+				},
+				cli.IntFlag{
+					Name:  "page-size",
+					Value: 0,
+					Usage: "Wait for Enter after this many lines on a terminal (0 disables paging)",
 				},
+				currencyFlag,
+				rateFlag,
+				sinceFlag,
+				reverseFlag,
+				outputFlag,
 			},
 		},
 		{
-			Name:   "delete",
-			Usage:  "Delete a transaction",
-			Action: deleteAction,
+			Name:   "today",
+			Usage:  "List the transactions of today and their totals",
+			Action: periodAction("today", db.DayOf),
+			Flags:  []cli.Flag{currencyFlag, rateFlag, reverseFlag},
+		},
+		{
+			Name:   "this-week",
+			Usage:  "List the transactions of the current week (from Monday) and their totals",
+			Action: periodAction("this week", db.WeekOf),
+			Flags:  []cli.Flag{currencyFlag, rateFlag, reverseFlag},
+		},
+		{
+			Name:   "this-month",
+			Usage:  "List the transactions of the current month and their totals",
+			Action: periodAction("this month", db.MonthOf),
+			Flags:  []cli.Flag{currencyFlag, rateFlag, reverseFlag},
+		},
+		{
+			Name:      "show",
+			Usage:     "Show all details of a transaction",
+			ArgsUsage: "<id>",
+			Action:    showAction,
+		},
+		{
+			Name:      "delete",
+			Usage:     "Delete a transaction",
+			ArgsUsage: "<id>",
+			Action:    deleteAction,
+			Before:    requireWritable,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "dry-run, n",
+					Usage: "Show what would be deleted without deleting",
+				},
+				yesFlag,
+			},
 		},
 		{
 			Name:   "filter",
@@ -300,22 +2409,517 @@ func main() {
 					Value: "",
 					Usage: "Filter transaction by type (withdraw or deposit)",
 				},
+				cli.StringFlag{
+					Name:  "around",
+					Value: "",
+					Usage: "Filter by approximate volume (in standard currency format)",
+				},
+				cli.StringFlag{
+					Name:  "tolerance",
+					Value: "",
+					Usage: "Allowed deviation from --around (in standard currency format)",
+				},
+				cli.StringFlag{
+					Name:  "from",
+					Value: "",
+					Usage: "Filter by earliest date (D.M.YYYY, inclusive)",
+				},
+				cli.StringFlag{
+					Name:  "to",
+					Value: "",
+					Usage: "Filter by latest date (D.M.YYYY, inclusive)",
+				},
+				cli.StringFlag{
+					Name:  "note",
+					Value: "",
+					Usage: "Filter by text within the note (case insensitive)",
+				},
+				cli.StringFlag{
+					Name:  "direction",
+					Value: "",
+					Usage: "Filter by money coming in (deposits) or going out (withdrawals)",
+				},
+				cli.StringSliceFlag{
+					Name:  "tag",
+					Usage: "Filter by tag, can be repeated to require all tags",
+				},
+				cli.BoolFlag{
+					Name:  "any",
+					Usage: "Require only one of the --tag values",
+				},
+				currencyFlag,
+				rateFlag,
+				sinceFlag,
+				reverseFlag,
+				outputFlag,
+			},
+		},
+		{
+			Name:   "serve",
+			Usage:  "Serve the database over HTTP",
+			Action: serveAction,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "addr",
+					Value: "localhost:8080",
+					Usage: "Address to listen on",
+				},
+			},
+		},
+		{
+			Name:      "import",
+			Usage:     "Import transactions from a bank statement",
+			ArgsUsage: "<file>",
+			Action:    importAction,
+			Before:    requireWritable,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "format",
+					Value: importFormatOFX,
+					Usage: "Format of the statement (ofx or csv)",
+				},
+				cli.StringFlag{
+					Name:  "assume-currency",
+					Value: "",
+					Usage: "Currency of the amounts without a known currency, required if the statement is not in the book currency",
+				},
+			},
+		},
+		{
+			Name:      "batch",
+			Usage:     "Store transactions listed as name,type,amount[,date] lines",
+			ArgsUsage: "<file>",
+			Action:    batchAction,
+			Before:    requireWritable,
+		},
+		{
+			Name:   "budget",
+			Usage:  "Show the monthly spendings per category compared to their limit",
+			Action: budgetAction,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "month, m",
+					Value: "",
+					Usage: "Month to inspect (M.YYYY), defaults to the current month",
+				},
+				rateFlag,
+			},
+			Subcommands: []cli.Command{
+				{
+					Name:      "set",
+					Usage:     "Set the monthly limit of a category",
+					ArgsUsage: "<category> <amount>",
+					Action:    budgetSetAction,
+					Before:    requireWritable,
+				},
+			},
+		},
+		{
+			Name:   "tags",
+			Usage:  "List all distinct names with their count and net total",
+			Action: tagsAction,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "category",
+					Usage: "List categories instead of names",
+				},
+				rateFlag,
+			},
+			Subcommands: []cli.Command{
+				{
+					Name:   "stats",
+					Usage:  "Show the share of every category in all withdrawals",
+					Action: tagsStatsAction,
+					Flags:  []cli.Flag{rateFlag},
+				},
+			},
+		},
+		{
+			Name:      "transfer",
+			Usage:     "Transfer money between two databases",
+			ArgsUsage: "<source> <destination>",
+			Action:    transferAction,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "amount, a",
+					Value: "",
+					Usage: "Amount to transfer (in the currency of the source database)",
+				},
+				cli.StringFlag{
+					Name:  "name, n",
+					Value: transferDefaultName,
+					Usage: "Name of both transactions",
+				},
+				cli.StringFlag{
+					Name:  "date, d",
+					Value: "",
+					Usage: "Date of the transfer (D.M.YYYY), defaults to now",
+				},
+				rateFlag,
+			},
+		},
+		{
+			Name:   "verify",
+			Usage:  "Check the database for invalid transactions",
+			Action: verifyAction,
+		},
+		{
+			Name:   "info",
+			Usage:  "Show an overview of the database and its file",
+			Action: infoAction,
+			Flags:  []cli.Flag{rateFlag},
+		},
+		{
+			Name:   "repair",
+			Usage:  "Remove invalid transactions from the database",
+			Action: repairAction,
+			Before: requireWritable,
+			Flags: []cli.Flag{
+				yesFlag,
+			},
+		},
+		{
+			Name:      "backup",
+			Usage:     "Save a timestamped copy of the database",
+			ArgsUsage: "[directory]",
+			Action:    backupAction,
+		},
+		{
+			Name:      "restore",
+			Usage:     "Replace the database with a backup",
+			ArgsUsage: "<path>",
+			Action:    restoreAction,
+			Before:    requireWritable,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "force, f",
+					Usage: "Disable any warnings",
+				},
+				yesFlag,
+			},
+		},
+		{
+			Name:   "summary",
+			Usage:  "Summarize deposits and withdrawals per period",
+			Action: summaryAction,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "period, p",
+					Value: summaryPeriodMonth,
+					Usage: "Length of a period (month or week)",
+				},
+				rawFlag,
+				decimalFlag,
+				currencyFlag,
+				rateFlag,
+				outputFlag,
+			},
+		},
+		{
+			Name:   "watch",
+			Usage:  "Show the latest transactions whenever the database changes",
+			Action: watchAction,
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  "limit, l",
+					Value: 10,
+					Usage: "Amount of entries shown (0 shows all)",
+				},
+				cli.DurationFlag{
+					Name:  "interval, i",
+					Value: time.Second,
+					Usage: "Time between checks for changes",
+				},
+			},
+		},
+		{
+			Name:      "clear",
+			Usage:     "Delete all transactions but keep the database",
+			ArgsUsage: "[archive directory]",
+			Action:    clearAction,
+			Before:    requireWritable,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "archive, a",
+					Usage: "Save a backup before clearing",
+				},
+				cli.BoolFlag{
+					Name:  "force, f",
+					Usage: "Disable any warnings",
+				},
+				yesFlag,
+			},
+		},
+		{
+			Name:      "duplicate",
+			Usage:     "Store an edited copy of a transaction",
+			ArgsUsage: "<id>",
+			Action:    duplicateAction,
+			Before:    requireWritable,
+		},
+		{
+			Name:   "count",
+			Usage:  "Count the transactions",
+			Action: countAction,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "type",
+					Value: "",
+					Usage: "Count transactions by type (withdraw or deposit)",
+				},
+				cli.StringFlag{
+					Name:  "from",
+					Value: "",
+					Usage: "Count by earliest date (D.M.YYYY, inclusive)",
+				},
+				cli.StringFlag{
+					Name:  "to",
+					Value: "",
+					Usage: "Count by latest date (D.M.YYYY, inclusive)",
+				},
+				cli.BoolFlag{
+					Name:  "quiet, q",
+					Usage: "Print only the number",
+				},
+			},
+		},
+		{
+			Name:   "export",
+			Usage:  "Export all transactions",
+			Action: exportAction,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "format",
+					Value: exportFormatQIF,
+					Usage: "Format of the export (qif or csv)",
+				},
+				cli.StringFlag{
+					Name:  "csv-locale",
+					Value: "",
+					Usage: "Number format of csv exports (en: 12.50 separated by commas, de: 12,50 separated by semicolons), defaults to --locale or en",
+				},
+				outputFlag,
+			},
+		},
+		{
+			Name:      "rename",
+			Usage:     "Change the name of the database",
+			ArgsUsage: "<name>",
+			Action:    renameAction,
+			Before:    requireWritable,
+		},
+		{
+			Name:      "rename-category",
+			Usage:     "Rename a category in all transactions",
+			ArgsUsage: "<old> <new>",
+			Action:    bulkRenameAction(db.RenameCategory),
+			Before:    requireWritable,
+		},
+		{
+			Name:      "rename-name",
+			Usage:     "Rename all transactions with the given name",
+			ArgsUsage: "<old> <new>",
+			Action:    bulkRenameAction(db.RenameName),
+			Before:    requireWritable,
+		},
+		{
+			Name:   "balance",
+			Usage:  "Show the current balance",
+			Action: balanceAction,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "per-day",
+					Usage: "Print the balance at the end of each day",
+				},
+				rawFlag,
+				decimalFlag,
+				cli.StringFlag{
+					Name:  "format",
+					Value: balanceFormatCSV,
+					Usage: "Format of the daily balances (csv or json)",
+				},
+				cli.StringFlag{
+					Name:  "from",
+					Value: "",
+					Usage: "First day of the daily balances (D.M.YYYY)",
+				},
+				cli.StringFlag{
+					Name:  "to",
+					Value: "",
+					Usage: "Last day of the daily balances (D.M.YYYY), defaults to today",
+				},
+				currencyFlag,
+				rateFlag,
+			},
+		},
+		{
+			Name:   "report",
+			Usage:  "Show the net amount per group",
+			Action: reportAction,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "group-by, g",
+					Value: "category",
+					Usage: "Key to group by (name, category, month or type)",
+				},
+				sinceFlag,
+				cli.BoolFlag{
+					Name:  "include-empty",
+					Usage: "Show known groups without transactions in the period as zero",
+				},
+				currencyFlag,
+				rateFlag,
+				outputFlag,
+			},
+		},
+		{
+			Name:   "edit",
+			Usage:  "Edit the raw database in $EDITOR",
+			Action: editAction,
+			Before: requireWritable,
+		},
+		{
+			Name:      "merge",
+			Usage:     "Append all transactions of another database",
+			ArgsUsage: "<other.trdb>",
+			Action:    mergeAction,
+			Before:    requireWritable,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "dedupe",
+					Usage: "Skip transactions that are already stored",
+				},
+			},
+		},
+		{
+			Name:      "reconcile",
+			Usage:     "Compare the database against a bank statement (csv), fails on differences",
+			ArgsUsage: "<statement.csv>",
+			Action:    reconcileAction,
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  "days",
+					Value: 3,
+					Usage: "Largest difference in days of matching transactions",
+				},
+			},
+		},
+		{
+			Name:    "first",
+			Aliases: []string{"oldest"},
+			Usage:   "Show the oldest transaction",
+			Action:  pickAction(db.Oldest),
+		},
+		{
+			Name:    "last",
+			Aliases: []string{"latest"},
+			Usage:   "Show the most recent transaction",
+			Action:  pickAction(db.Latest),
+		},
+		{
+			Name:   "compact",
+			Usage:  "Rewrite the database, dropping deleted entries of .trjl files",
+			Action: compactAction,
+			Before: requireWritable,
+		},
+		{
+			Name:   "top",
+			Usage:  "Show the transactions with the largest amounts",
+			Action: topAction,
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  "n",
+					Value: 5,
+					Usage: "Number of transactions shown",
+				},
+				cli.StringFlag{
+					Name:  "type, t",
+					Value: "",
+					Usage: "Only show withdrawals (wd) or deposits (dp)",
+				},
+				currencyFlag,
+				rateFlag,
+			},
+		},
+		{
+			Name:   "average",
+			Usage:  "Show the average net amount per month",
+			Action: averageAction,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "by-category",
+					Usage: "Average every category on its own",
+				},
+				cli.BoolFlag{
+					Name:  "prorate",
+					Usage: "Count partial first and last months by their covered days instead of leaving them out",
+				},
+				currencyFlag,
+				rateFlag,
 			},
 		},
 	}
-	app.Run(os.Args)
+	return app
 }
 
 func getInput() (string, error) {
 	input, err := console.ReadString('\n')
-	if err != nil {
+	if err == io.EOF && input != "" {
+		return strings.TrimSpace(input), nil
+	} else if err != nil {
 		return "", err
 	}
 	return strings.TrimSpace(input), nil
 }
 
+// inputError explains a failed prompt, e.g. on a closed standard input.
+func inputError(err error) error {
+	if err == io.EOF {
+		return cli.NewExitError("\n"+endOfInputMessage, exitGeneric)
+	}
+	return err
+}
+
+// setTimeFormat selects the display format by name (iso, us) or fmtdate pattern.
+func setTimeFormat(format string) {
+	switch strings.ToLower(format) {
+	case "", "default":
+		displayTimeFormat = ""
+	case "iso":
+		displayTimeFormat = isoTimeFormat
+	case "us":
+		displayTimeFormat = usTimeFormat
+	default:
+		displayTimeFormat = format
+	}
+}
+
 func formatTime(t time.Time) string {
-	return fmt.Sprintf(transactionTimeFormat, t.Day(), t.Month(), t.Year(), t.Hour(), t.Minute())
+	if displayTimeFormat != "" {
+		return fmtdate.Format(displayTimeFormat, t)
+	}
+	return fmt.Sprintf(transactionTimeFormat, t.Day(), monthName(t.Month(), displayLocale), t.Year(), t.Hour(), t.Minute())
+}
+
+// monthName returns the name of the month in the given language,
+// falling back to English for unknown locales.
+func monthName(m time.Month, locale string) string {
+	if names, ok := localMonthNames[locale]; ok && m >= time.January && m <= time.December {
+		return names[m-1]
+	}
+	return m.String()
+}
+
+// localeFromEnv reads the language of dates from $LC_ALL, $LC_TIME or $LANG,
+// e.g. "de" for de_DE.UTF-8.
+func localeFromEnv() string {
+	for _, name := range []string{"LC_ALL", "LC_TIME", "LANG"} {
+		fields := strings.FieldsFunc(os.Getenv(name), func(r rune) bool { return r == '_' || r == '.' || r == '-' })
+		if len(fields) > 0 {
+			return strings.ToLower(fields[0])
+		}
+	}
+	return ""
 }
 
 func validIndex(x, max int) bool {
@@ -0,0 +1,1823 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lnsp/transaction/db"
+	"github.com/urfave/cli"
+)
+
+// testContext builds the context of a command invoked with the given string flags and arguments.
+func testContext(flags map[string]string, args ...string) *cli.Context {
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	for name, value := range flags {
+		set.String(name, value, "")
+	}
+	set.Parse(append([]string{"--"}, args...))
+	return cli.NewContext(nil, set, nil)
+}
+
+// testDatabase is the database runApp runs against.
+func testDatabase() db.Database {
+	database := db.NewDatabase("test")
+	database.Store(db.NewTransaction("Salary", db.Deposit, 100000, time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)))
+	return database
+}
+
+// writeTestDatabase stores the database in a temporary file and returns its path.
+func writeTestDatabase(t *testing.T, database db.Database) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.trdb")
+	if err := db.WriteFile(path, database); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// runAppAt runs the command line with the given arguments against the database at path
+// and returns what it printed to stdout and the exit code.
+func runAppAt(t *testing.T, path string, args ...string) (string, int) {
+	t.Helper()
+	defer db.SetPath("")
+	exiter, errWriter := cli.OsExiter, cli.ErrWriter
+	defer func() { cli.OsExiter, cli.ErrWriter = exiter, errWriter }()
+	cli.OsExiter, cli.ErrWriter = func(int) {}, ioutil.Discard
+	app := newApp()
+	app.Writer = ioutil.Discard
+	var err error
+	output := captureStdout(t, func() {
+		err = app.Run(append([]string{"transaction", "--db", path}, args...))
+	})
+	if err == nil {
+		return output, 0
+	}
+	return output, exitCode(err)
+}
+
+// runApp runs the command line with the given arguments against a fresh test database
+// and returns the exit code.
+func runApp(t *testing.T, args ...string) int {
+	t.Helper()
+	_, code := runAppAt(t, writeTestDatabase(t, testDatabase()), args...)
+	return code
+}
+
+// rowNames lists the names in the rows of a transaction table printed in the plain style.
+func TestCurrencyFlag(t *testing.T) {
+	// A currency of its own keeps the exchange rate from leaking into other tests.
+	db.RegisterCurrency(db.Currency{Name: "FlagCrown", Format: "%d.%0*d", Ratio: 100, Symbol: " kr"})
+	path := writeTestDatabase(t, testDatabase())
+	tests := []struct {
+		args []string
+		want string
+		code int
+	}{
+		{[]string{"balance"}, "test has a balance of 1000.00€.\n", 0},
+		{[]string{"balance", "--currency", "FlagCrown", "--rate", "euro=10"}, "test has a balance of 10000.00 kr.\n", 0},
+		{[]string{"balance", "--currency", "FlagCrown", "--rate", "euro=10", "--raw"}, "1000000\n", 0},
+		{[]string{"balance", "--currency", "unknown"}, "", exitValidation},
+	}
+	for _, test := range tests {
+		got, code := runAppAt(t, path, test.args...)
+		if got != test.want || code != test.code {
+			t.Errorf("%s: got %q (exit code %d), want %q (exit code %d)", strings.Join(test.args, " "), got, code, test.want, test.code)
+		}
+	}
+	for _, args := range [][]string{
+		{"list"},
+		{"filter", "--name", "Salary"},
+		{"report", "--group-by", "name"},
+	} {
+		args = append(args, "--currency", "FlagCrown", "--rate", "euro=10")
+		got, code := runAppAt(t, path, args...)
+		if !strings.Contains(got, "10000.00 kr") || code != 0 {
+			t.Errorf("%s: got %q (exit code %d), want amounts in FlagCrown", strings.Join(args, " "), got, code)
+		}
+	}
+	if db.DefaultCurrency.Name != db.Euro.Name {
+		t.Errorf("the display currency %s leaked out of the command", db.DefaultCurrency.Name)
+	}
+	// The book currency of the database must not leak out either.
+	dollars := testDatabase()
+	dollars.Currency = db.USDollar.Name
+	runAppAt(t, writeTestDatabase(t, dollars), "balance")
+	if db.DefaultCurrency.Name != db.Euro.Name {
+		t.Errorf("the book currency %s leaked out of the command", db.DefaultCurrency.Name)
+	}
+}
+
+func rowNames(output string) []string {
+	var names []string
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if fields := strings.Split(line, "\t"); len(fields) > 2 {
+			names = append(names, fields[2])
+		}
+	}
+	return names
+}
+
+func TestUseCurrency(t *testing.T) {
+	defer func(currency db.Currency) { db.DefaultCurrency = currency }(db.DefaultCurrency)
+	if err := useCurrency(testContext(map[string]string{"currency": "dollar"})); err != nil {
+		t.Fatal(err)
+	}
+	if db.DefaultCurrency != db.Dollar {
+		t.Errorf("got display currency %s, want %s", db.DefaultCurrency.Name, db.Dollar.Name)
+	}
+	if err := useCurrency(testContext(map[string]string{"currency": "unknown"})); err == nil || db.DefaultCurrency != db.Dollar {
+		t.Errorf("unknown currency: got %s, %v, want an error", db.DefaultCurrency.Name, err)
+	}
+}
+
+func TestOpenDisplayDatabase(t *testing.T) {
+	book := testDatabase()
+	book.Currency = db.Dollar.Name
+	path := writeTestDatabase(t, book)
+	tests := []struct {
+		flags map[string]string
+		want  string
+	}{
+		{nil, "1000.00$"},
+		{map[string]string{"currency": "euro"}, "1000.00€"},
+	}
+	for _, test := range tests {
+		db.SetPath(path)
+		_, restore, err := openDisplayDatabase(testContext(test.flags))
+		db.SetPath("")
+		if got := db.Value(100000).String(); err != nil || got != test.want {
+			t.Errorf("%v: got %s (%v), want %s", test.flags, got, err, test.want)
+		}
+		restore()
+		// The book currency of the database must not leak into later commands.
+		if db.DefaultCurrency != db.Euro {
+			t.Errorf("%v: got display currency %s after restoring, want %s", test.flags, db.DefaultCurrency.Name, db.Euro.Name)
+		}
+	}
+}
+
+func TestPrintTransactionTableMixedCurrencies(t *testing.T) {
+	date := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+	salary := db.NewTransaction("Salary", db.Deposit, 100000, date)
+	salary.Currency = db.Euro.Name
+	// A currency of its own keeps the exchange rate from leaking into other tests.
+	peso := db.Currency{Name: "TablePeso", Format: "%d.%0*d", Ratio: 100, Symbol: "P"}
+	db.RegisterCurrency(peso)
+	hotel := db.NewTransaction("Hotel", db.Withdraw, 10000, date)
+	hotel.Currency = peso.Name
+	entries := []tableEntry{{0, salary}, {1, hotel}}
+	var buf bytes.Buffer
+	printTransactionTable(&buf, "test", entries, false)
+	for _, want := range []string{"1000.00€", "100.00P", unconvertibleAmount} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("missing %s in\n%s", want, buf.String())
+		}
+	}
+	db.SetRate(peso.Name, db.Euro.Name, 0.5)
+	buf.Reset()
+	printTransactionTable(&buf, "test", entries, false)
+	if !strings.Contains(buf.String(), "950.00€") || strings.Contains(buf.String(), unconvertibleAmount) {
+		t.Errorf("missing the converted balance in\n%s", buf.String())
+	}
+}
+
+func TestSummaryMissingRate(t *testing.T) {
+	database := testDatabase()
+	hotel := db.NewTransaction("Hotel", db.Withdraw, 10000, time.Date(2016, 3, 2, 0, 0, 0, 0, time.UTC))
+	hotel.Currency = db.Dollar.Name
+	database.Store(hotel)
+	path := writeTestDatabase(t, database)
+	if _, code := runAppAt(t, path, "summary"); code != exitValidation {
+		t.Errorf("got exit code %d, want %d for a missing rate", code, exitValidation)
+	}
+	output, code := runAppAt(t, path, "filter")
+	if code != 0 || !strings.HasSuffix(output, fmt.Sprintf(filterTotalsMessage, unconvertibleAmount, unconvertibleAmount, unconvertibleAmount)) {
+		t.Errorf("got\n%s\n(exit code %d), want unconvertible totals", output, code)
+	}
+}
+
+func TestBudgetSetInvalid(t *testing.T) {
+	for _, args := range [][]string{{}, {"Food"}, {"Food", "-5"}} {
+		if err := budgetSetAction(testContext(nil, args...)); err == nil {
+			t.Errorf("%v: got no error", args)
+		}
+	}
+}
+
+func TestParseDateFlag(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Time
+		ok    bool
+	}{
+		{"", time.Time{}, true},
+		{"1.3.2016", time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC), true},
+		{"31.12.2016", time.Date(2016, 12, 31, 0, 0, 0, 0, time.UTC), true},
+		{"yesterday", time.Time{}, false},
+		{"2016-03-01", time.Time{}, false},
+	}
+	for _, test := range tests {
+		got, err := parseDateFlag(testContext(map[string]string{"from": test.value}), "from")
+		if (err == nil) != test.ok || !got.Equal(test.want) {
+			t.Errorf("%q: got %v, %v, want %v", test.value, got, err, test.want)
+		}
+	}
+}
+
+// captureStdout returns everything the function prints to stdout.
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	f()
+	os.Stdout = stdout
+	w.Close()
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestTransactionTableAlignment(t *testing.T) {
+	defer func(style string, width int) { tableStyle, tableWidth = style, width }(tableStyle, tableWidth)
+	tableStyle, tableWidth = tableStyleASCII, defaultTableWidth
+	date := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+	entries := []tableEntry{
+		{0, db.NewTransaction("Tip", db.Withdraw, 5, date)},
+		{1, db.NewTransaction("Lottery", db.Deposit, 123456789000, date)},
+		{2, db.NewTransaction("Coffee", db.Withdraw, 250, date)},
+	}
+	var buf bytes.Buffer
+	printTransactionTable(&buf, "test", entries, false)
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	rows, total := lines[1:4], lines[len(lines)-1]
+	// Rows fill the table and the total ends below the amounts, counted in runes rather than bytes.
+	amountWidth := utf8.RuneCountInString("1234567889.95€")
+	for _, row := range rows {
+		if utf8.RuneCountInString(row) != tableWidth {
+			t.Errorf("row %q is %d runes wide, want %d", row, utf8.RuneCountInString(row), tableWidth)
+		}
+	}
+	if n := utf8.RuneCountInString(total); n != tableWidth-amountWidth-1 {
+		t.Errorf("total %q is %d runes wide, want %d", total, n, tableWidth-amountWidth-1)
+	}
+	if !strings.Contains(rows[1], " 1234567890.00€ ") {
+		t.Errorf("the large amount is cut in %q", rows[1])
+	}
+}
+
+func TestShowInvalidID(t *testing.T) {
+	for _, id := range []string{"", "x", "1.5"} {
+		err := showAction(testContext(nil, id))
+		if err == nil || err.Error() != fmt.Sprintf(invalidTransactionIDMessage, id) {
+			t.Errorf("%q: got %v, want an invalid ID error", id, err)
+		}
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	defer func(format, locale string) { displayTimeFormat, displayLocale = format, locale }(displayTimeFormat, displayLocale)
+	displayLocale = "en"
+	date := time.Date(2016, 3, 7, 9, 5, 0, 0, time.UTC)
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"", "07. March 2016 09:05"},
+		{"iso", "2016-03-07 09:05"},
+		{"us", "03/07/2016 09:05"},
+		{"DD.MM.YYYY", "07.03.2016"},
+	}
+	for _, test := range tests {
+		setTimeFormat(test.format)
+		if got := formatTime(date); got != test.want {
+			t.Errorf("%q: got %s, want %s", test.format, got, test.want)
+		}
+	}
+}
+
+func TestRunningBalances(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2016, 3, d, 0, 0, 0, 0, time.UTC) }
+	// Displayed newest first, with a backdated entry stored last.
+	entries := []tableEntry{
+		{2, db.NewTransaction("Rent", db.Withdraw, 50000, day(3))},
+		{1, db.NewTransaction("Coffee", db.Withdraw, 250, day(2))},
+		{3, db.NewTransaction("Gift", db.Deposit, 3000, day(2))},
+		{0, db.NewTransaction("Salary", db.Deposit, 100000, day(1))},
+	}
+	running, convertible := runningBalances(entries)
+	// Salary 1000.00, Coffee 997.50, Gift 1027.50, Rent 527.50.
+	want := []db.Value{52750, 99750, 102750, 100000}
+	if !convertible {
+		t.Fatal("got unconvertible balances")
+	}
+	for i := range want {
+		if running[i] != want[i] {
+			t.Errorf("%s: got %v, want %v", entries[i].transact.Name, running[i], want[i])
+		}
+	}
+}
+
+func TestDatabaseError(t *testing.T) {
+	_, err := os.Open(filepath.Join(t.TempDir(), "missing.trdb"))
+	if got := databaseError(err); got == nil || got.Error() != noDatabaseMessage {
+		t.Errorf("got %v, want %q", got, noDatabaseMessage)
+	}
+	other := fmt.Errorf("disk on fire")
+	if got := databaseError(other); got != other {
+		t.Errorf("got %v, want the error unchanged", got)
+	}
+	if got := databaseError(nil); got != nil {
+		t.Errorf("got %v, want no error", got)
+	}
+}
+
+func TestReverse(t *testing.T) {
+	path := writeTestDatabase(t, numberedDatabase(3))
+	for _, command := range []string{"list", "filter"} {
+		for _, reverse := range []bool{false, true} {
+			args := []string{"--style", "plain", "--no-header", command}
+			want := "#1 #2 #3"
+			if reverse {
+				args, want = append(args, "--reverse"), "#3 #2 #1"
+			}
+			output, code := runAppAt(t, path, args...)
+			if got := strings.Join(rowNames(output), " "); code != 0 || got != want {
+				t.Errorf("%v: got %q (exit code %d), want %q", args, got, code, want)
+			}
+		}
+	}
+}
+
+func TestParseAction(t *testing.T) {
+	tests := map[string]db.Action{
+		"+":        db.Deposit,
+		"-":        db.Withdraw,
+		" DP ":     db.Deposit,
+		"depo":     db.Deposit,
+		"Withdraw": db.Withdraw,
+		"wd":       db.Withdraw,
+		"":         "",
+		"++":       "",
+	}
+	for text, want := range tests {
+		if got := parseAction(text); got != want {
+			t.Errorf("parseAction(%q): got %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestStoreInvalidFlags(t *testing.T) {
+	tests := []struct {
+		flags map[string]string
+		want  string
+	}{
+		{map[string]string{"name": "Salary", "type": "deposit", "amount": "1000", "date": "yesterday"}, "invalid --date 'yesterday'"},
+		{map[string]string{"name": "Salary", "type": "deposit", "amount": "ten", "date": "1.3.2016"}, "invalid --amount amount 'ten'"},
+	}
+	for _, test := range tests {
+		err := storeAction(testContext(test.flags))
+		if err == nil || !strings.HasPrefix(err.Error(), test.want) {
+			t.Errorf("%v: got %v, want %q", test.flags, err, test.want)
+		}
+	}
+}
+
+func TestMarkOverBudget(t *testing.T) {
+	db.SetPath(filepath.Join(t.TempDir(), "test.trdb"))
+	defer db.SetPath("")
+	if err := db.WriteBudget(map[string]db.Value{"Food": 3000}); err != nil {
+		t.Fatal(err)
+	}
+	database := db.NewDatabase("test")
+	march, april := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2016, 4, 1, 0, 0, 0, 0, time.UTC)
+	for _, transact := range []db.Transaction{
+		db.NewTransaction("Groceries", db.Withdraw, 2500, march),
+		db.NewTransaction("Dinner", db.Withdraw, 1000, march),
+		db.NewTransaction("Lunch", db.Withdraw, 1000, april),
+		db.NewTransaction("Rent", db.Withdraw, 50000, march),
+	} {
+		if transact.Name != "Rent" {
+			transact.Category = "Food"
+		}
+		database.Store(transact)
+	}
+	var entries []tableEntry
+	database.Each(func(id int, transact db.Transaction) bool {
+		entries = append(entries, tableEntry{id, transact})
+		return true
+	})
+	marked, err := markOverBudget(database, entries)
+	if err != nil || !marked {
+		t.Fatalf("got %v, %v, want marked entries", marked, err)
+	}
+	for _, entry := range entries {
+		over := entry.transact.Date.Equal(march) && entry.transact.Category == "Food"
+		if strings.HasSuffix(entry.transact.Name, budgetMarker) != over {
+			t.Errorf("%s: got marker %v, want %v", entry.transact.Name, !over, over)
+		}
+	}
+}
+
+func TestBudgetInvalidMonth(t *testing.T) {
+	path := writeTestDatabase(t, testDatabase())
+	for _, month := range []string{"13.2016", "march", "1.3.2016"} {
+		if _, code := runAppAt(t, path, "budget", "--month", month); code != exitValidation {
+			t.Errorf("%q: got exit code %d, want %d", month, code, exitValidation)
+		}
+	}
+	if _, code := runAppAt(t, path, "budget", "--month", "03.2016"); code != 0 {
+		t.Errorf("got exit code %d", code)
+	}
+}
+
+func TestFilterDateRange(t *testing.T) {
+	database := db.NewDatabase("test")
+	for _, transact := range []db.Transaction{
+		db.NewTransaction("Before", db.Withdraw, 100, time.Date(2016, 2, 29, 23, 59, 0, 0, time.UTC)),
+		db.NewTransaction("First", db.Withdraw, 100, time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)),
+		db.NewTransaction("Last", db.Withdraw, 100, time.Date(2016, 3, 31, 23, 59, 0, 0, time.UTC)),
+		db.NewTransaction("After", db.Withdraw, 100, time.Date(2016, 4, 1, 0, 0, 0, 0, time.UTC)),
+	} {
+		database.Store(transact)
+	}
+	path := writeTestDatabase(t, database)
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--from", "1.3.2016", "--to", "31.3.2016"}, "First Last"},
+		{[]string{"--from", "1.3.2016"}, "First Last After"},
+		{[]string{"--to", "29.2.2016"}, "Before"},
+		{[]string{"--from", "1.4.2016", "--to", "1.4.2016"}, "After"},
+		{[]string{"--from", "2.4.2016"}, ""},
+		{[]string{"--to", "31.3.2016 12:00"}, "Before First"},
+		{[]string{"--to", "31.3.2016 23:59"}, "Before First Last"},
+		{[]string{"--from", "1.3.2016", "--to", "1.3.2016 0:00"}, "First"},
+	}
+	for _, test := range tests {
+		output, code := runAppAt(t, path, append([]string{"--style", "plain", "--no-header", "filter"}, test.args...)...)
+		if got := strings.Join(rowNames(output), " "); code != 0 || got != test.want {
+			t.Errorf("%s: got %q (exit code %d), want %q", strings.Join(test.args, " "), got, code, test.want)
+		}
+	}
+	output, _ := runAppAt(t, path, "--style", "plain", "filter", "--from", "1.3.2016", "--to", "31.3.2016")
+	if !strings.Contains(output, "from='1.3.2016', to='31.3.2016'") {
+		t.Errorf("missing the range in the header of\n%s", output)
+	}
+}
+
+func TestFilterAround(t *testing.T) {
+	database := db.NewDatabase("test")
+	date := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, amount := range []db.Value{4499, 4500, 5000, 5500, 5501} {
+		database.Store(db.NewTransaction(fmt.Sprintf("#%d", amount), db.Withdraw, amount, date))
+	}
+	path := writeTestDatabase(t, database)
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--around", "50", "--tolerance", "5"}, "#4500 #5000 #5500"},
+		{[]string{"--around", "50"}, "#5000"},
+	}
+	for _, test := range tests {
+		output, code := runAppAt(t, path, append([]string{"--style", "plain", "filter"}, test.args...)...)
+		if got := strings.Join(rowNames(output), " "); code != 0 || got != test.want {
+			t.Errorf("%s: got %q (exit code %d), want %q", strings.Join(test.args, " "), got, code, test.want)
+		}
+	}
+}
+
+func TestTagsSortedByTotal(t *testing.T) {
+	database := testDatabase()
+	date := time.Date(2016, 3, 2, 0, 0, 0, 0, time.UTC)
+	database.Store(db.NewTransaction("Coffee", db.Withdraw, 250, date))
+	database.Store(db.NewTransaction("Rent", db.Withdraw, 50000, date))
+	database.Store(db.NewTransaction("Gift", db.Deposit, 3000, date))
+	output, code := runAppAt(t, writeTestDatabase(t, database), "--style", "compact", "tags")
+	want := "Salary 1 1000.00€\nGift 1 30.00€\nCoffee 1 -2.50€\nRent 1 -500.00€\n"
+	if code != 0 || output != want {
+		t.Errorf("got %q (exit code %d), want %q", output, code, want)
+	}
+}
+
+// numberedDatabase has n withdrawals named #1 to #n on consecutive days.
+func numberedDatabase(n int) db.Database {
+	database := db.NewDatabase("test")
+	for i := 1; i <= n; i++ {
+		database.Store(db.NewTransaction(fmt.Sprintf("#%d", i), db.Withdraw, db.Value(i*100), time.Date(2016, 3, i, 0, 0, 0, 0, time.UTC)))
+	}
+	return database
+}
+
+func TestListLimit(t *testing.T) {
+	path := writeTestDatabase(t, numberedDatabase(12))
+	tests := []struct {
+		limit string
+		rows  int
+		first string
+	}{
+		{"", 10, "#3"},
+		{"3", 3, "#10"},
+		{"0", 12, "#1"},
+		{"-1", 12, "#1"},
+	}
+	for _, test := range tests {
+		args := []string{"--style", "plain", "--no-header", "list"}
+		if test.limit != "" {
+			args = append(args, "--limit", test.limit)
+		}
+		output, code := runAppAt(t, path, args...)
+		names := rowNames(output)
+		if code != 0 || len(names) != test.rows || names[0] != test.first || names[len(names)-1] != "#12" {
+			t.Errorf("--limit %s: got %v (exit code %d), want %d rows from %s to #12", test.limit, names, code, test.rows, test.first)
+		}
+	}
+}
+
+func TestFilterTotals(t *testing.T) {
+	database := numberedDatabase(3)
+	database.Store(db.NewTransaction("Salary", db.Deposit, 100000, time.Date(2016, 3, 4, 0, 0, 0, 0, time.UTC)))
+	output, code := runAppAt(t, writeTestDatabase(t, database), "filter", "--min", "2")
+	want := fmt.Sprintf(filterTotalsMessage, db.Value(100000), db.Value(500), db.Value(99500))
+	if code != 0 || !strings.HasSuffix(output, want) {
+		t.Errorf("got\n%s\nwant it to end in %q", output, want)
+	}
+}
+
+func TestInitAtPath(t *testing.T) {
+	defer func(reader *bufio.Reader) { console = reader }(console)
+	path := filepath.Join(t.TempDir(), "book.trdb")
+	console = bufio.NewReader(strings.NewReader("My Book\n"))
+	if _, code := runAppAt(t, path, "init"); code != 0 {
+		t.Fatalf("got exit code %d", code)
+	}
+	database, err := db.OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if database.Name != "My Book" || database.Size() != 0 {
+		t.Errorf("got %+v, want an empty database named My Book", database)
+	}
+	// The confirmation names the existing file at the given path.
+	console = bufio.NewReader(strings.NewReader("n\n"))
+	output, _ := runAppAt(t, path, "init")
+	if !strings.Contains(output, path) {
+		t.Errorf("missing %s in the confirmation\n%s", path, output)
+	}
+	// The book currency is chosen on creation.
+	path = filepath.Join(t.TempDir(), "dollar.trdb")
+	console = bufio.NewReader(strings.NewReader("Travel\n"))
+	if _, code := runAppAt(t, path, "init", "--currency", "dollar"); code != 0 {
+		t.Fatalf("got exit code %d", code)
+	}
+	if database, err := db.OpenFile(path); err != nil || database.BookCurrency() != db.Dollar {
+		t.Errorf("got book currency %s (%v), want %s", database.BookCurrency().Name, err, db.Dollar.Name)
+	}
+}
+
+func TestSummaryPeriod(t *testing.T) {
+	path := writeTestDatabase(t, testDatabase())
+	output, code := runAppAt(t, path, "summary", "--period", "week")
+	if code != 0 || !strings.Contains(output, "Week 09 2016") {
+		t.Errorf("got\n%s\n(exit code %d), want week 9 of 2016", output, code)
+	}
+	if _, code := runAppAt(t, path, "summary", "--period", "year"); code != exitValidation {
+		t.Errorf("unknown period: got exit code %d, want %d", code, exitValidation)
+	}
+}
+
+// syncBuffer is a buffer safe for concurrent writes and reads.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestWatchDatabase(t *testing.T) {
+	path := writeTestDatabase(t, testDatabase())
+	db.SetPath(path)
+	defer db.SetPath("")
+	var out syncBuffer
+	stop, done := make(chan os.Signal, 1), make(chan error)
+	go func() { done <- watchDatabase(&out, 10, 5*time.Millisecond, stop) }()
+	waitFor := func(s string) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); !strings.Contains(out.String(), s); time.Sleep(5 * time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("missing %q in\n%s", s, out.String())
+			}
+		}
+	}
+	waitFor("Salary")
+	database := testDatabase()
+	database.Store(db.NewTransaction("Coffee", db.Withdraw, 250, time.Date(2016, 3, 2, 0, 0, 0, 0, time.UTC)))
+	if err := db.WriteFile(path, database); err != nil {
+		t.Fatal(err)
+	}
+	// Make sure the change is visible even on file systems with coarse timestamps.
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	waitFor("Coffee")
+	stop <- os.Interrupt
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(out.String(), clearScreenSequence); n != 2 {
+		t.Errorf("rendered %d times, want 2", n)
+	}
+}
+
+func TestFilterType(t *testing.T) {
+	database := testDatabase()
+	database.Store(db.NewTransaction("Rent", db.Withdraw, 50000, time.Date(2016, 3, 2, 0, 0, 0, 0, time.UTC)))
+	path := writeTestDatabase(t, database)
+	tests := []struct {
+		typ  string
+		code int
+		want string
+	}{
+		{"dp", 0, "Salary"},
+		{"deposit", 0, "Salary"},
+		{"wd", 0, "Rent"},
+		{"draw", 0, "Rent"},
+		{"foo", exitValidation, ""},
+	}
+	for _, test := range tests {
+		output, code := runAppAt(t, path, "--style", "plain", "--no-header", "filter", "--type", test.typ)
+		if got := strings.Join(rowNames(output), " "); code != test.code || got != test.want {
+			t.Errorf("--type %s: got %q (exit code %d), want %q (exit code %d)", test.typ, got, code, test.want, test.code)
+		}
+	}
+}
+
+func TestTableWidths(t *testing.T) {
+	defer func(width int) { tableWidth = width }(tableWidth)
+	path := writeTestDatabase(t, numberedDatabase(3))
+	tests := []struct {
+		columns string
+		args    []string
+		width   int
+	}{
+		{"", nil, defaultTableWidth},
+		{"100", nil, 100},
+		{"100", []string{"--width", "120"}, 120},
+		{"garbage", nil, defaultTableWidth},
+	}
+	for _, test := range tests {
+		t.Setenv("COLUMNS", test.columns)
+		output, code := runAppAt(t, path, append(append([]string{"--style", "ascii"}, test.args...), "list")...)
+		lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+		if code != 0 || len(lines) < 4 {
+			t.Fatalf("COLUMNS=%s %v: unexpected output (exit code %d)\n%s", test.columns, test.args, code, output)
+		}
+		// The header and the three rows span the full width.
+		for _, line := range lines[:4] {
+			if n := utf8.RuneCountInString(line); n != test.width {
+				t.Errorf("COLUMNS=%s %v: line %q is %d runes wide, want %d", test.columns, test.args, line, n, test.width)
+			}
+		}
+	}
+}
+
+func TestImportDuplicates(t *testing.T) {
+	statement, err := ioutil.ReadFile(filepath.Join("db", "testdata", "statement.ofx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A second coffee with the same content, but an institution ID of its own.
+	second := strings.Replace(string(statement), "</BANKTRANLIST>", "<STMTTRN>\n<TRNTYPE>DEBIT\n<DTPOSTED>20160302\n<TRNAMT>-12.50\n<FITID>2016030200003\n<MEMO>Coffee Shop\n</STMTTRN>\n</BANKTRANLIST>", 1)
+	file := filepath.Join(t.TempDir(), "statement.ofx")
+	if err := ioutil.WriteFile(file, []byte(second), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := writeTestDatabase(t, db.NewDatabase("test"))
+	for _, want := range []string{fmt.Sprintf(importSuccessMessage, 3, 3, 0, 0, 3), fmt.Sprintf(importSuccessMessage, 3, 0, 3, 0, 3)} {
+		output, code := runAppAt(t, path, "import", "--format", importFormatOFX, file)
+		if code != 0 || output != want {
+			t.Errorf("got %q (exit code %d), want %q", output, code, want)
+		}
+	}
+}
+
+func TestClear(t *testing.T) {
+	path := writeTestDatabase(t, testDatabase())
+	archive := t.TempDir()
+	if _, code := runAppAt(t, path, "clear", "--force", "--archive", archive); code != 0 {
+		t.Fatalf("got exit code %d", code)
+	}
+	database, err := db.OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if database.Size() != 0 || database.Name != testDatabase().Name {
+		t.Errorf("got %d transactions in %q, want none in %q", database.Size(), database.Name, testDatabase().Name)
+	}
+	backups, err := filepath.Glob(filepath.Join(archive, "transaction-*.trdb"))
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("got backups %v, %v, want one", backups, err)
+	}
+	archived, err := db.OpenFile(backups[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archived.Size() != 1 {
+		t.Errorf("got %d archived transactions, want 1", archived.Size())
+	}
+}
+
+func TestDuplicate(t *testing.T) {
+	defer func(reader *bufio.Reader) { console = reader }(console)
+	path := writeTestDatabase(t, testDatabase())
+	// Rename the clone and date it, keeping the type, amount, category and note.
+	console = bufio.NewReader(strings.NewReader("Bonus\n2.3.2016\n\n\n\n\n"))
+	if _, code := runAppAt(t, path, "duplicate", "0"); code != 0 {
+		t.Fatalf("got exit code %d", code)
+	}
+	database, err := db.OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if database.Size() != 2 {
+		t.Fatalf("got %d transactions, want 2", database.Size())
+	}
+	original, clone := database.Transactions[0], database.Transactions[1]
+	if want := testDatabase().Transactions[0]; original.Name != want.Name || original.Amount != want.Amount || !original.Date.Equal(want.Date) {
+		t.Errorf("the original changed to %+v", original)
+	}
+	if clone.Name != "Bonus" || clone.Type != db.Deposit || clone.Amount != original.Amount || clone.Date.Day() != 2 {
+		t.Errorf("got clone %+v, want Bonus deposit of %v on 2.3.2016", clone, original.Amount)
+	}
+}
+
+func TestCount(t *testing.T) {
+	database := numberedDatabase(5)
+	database.Store(db.NewTransaction("Salary", db.Deposit, 100000, time.Date(2016, 4, 1, 0, 0, 0, 0, time.UTC)))
+	path := writeTestDatabase(t, database)
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, "6"},
+		{[]string{"--type", "wd"}, "5"},
+		{[]string{"--type", "dp"}, "1"},
+		{[]string{"--from", "3.3.2016", "--to", "31.3.2016"}, "3"},
+		{[]string{"--type", "dp", "--to", "31.3.2016"}, "0"},
+	}
+	for _, test := range tests {
+		output, code := runAppAt(t, path, append([]string{"count", "-q"}, test.args...)...)
+		if got := strings.TrimSpace(output); code != 0 || got != test.want {
+			t.Errorf("%s: got %q (exit code %d), want %s", strings.Join(test.args, " "), got, code, test.want)
+		}
+	}
+}
+
+func TestFilterAmountBounds(t *testing.T) {
+	path := writeTestDatabase(t, numberedDatabase(3))
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, "#1 #2 #3"},
+		{[]string{"--max", "0"}, ""},
+		{[]string{"--min", "0"}, "#1 #2 #3"},
+		{[]string{"--max", "2"}, "#1 #2"},
+		{[]string{"--min", "2"}, "#2 #3"},
+		{[]string{"--min", "0", "--max", "0.00"}, ""},
+	}
+	for _, test := range tests {
+		output, code := runAppAt(t, path, append([]string{"--style", "plain", "--no-header", "filter"}, test.args...)...)
+		if got := strings.Join(rowNames(output), " "); code != 0 || got != test.want {
+			t.Errorf("%s: got %q (exit code %d), want %q", strings.Join(test.args, " "), got, code, test.want)
+		}
+	}
+}
+
+func TestStoreEndOfInput(t *testing.T) {
+	defer func(reader *bufio.Reader) { console = reader }(console)
+	for _, input := range []string{"", "Coffee\n", "Coffee\nwithdraw\n", "Coffee\n\nwithdraw\nabc\n"} {
+		console = bufio.NewReader(strings.NewReader(input))
+		if code := runApp(t, "store"); code != exitGeneric {
+			t.Errorf("%q: got exit code %d, want %d", input, code, exitGeneric)
+		}
+	}
+}
+
+func TestRename(t *testing.T) {
+	path := writeTestDatabase(t, testDatabase())
+	if _, code := runAppAt(t, path, "rename", "  "); code != exitValidation {
+		t.Errorf("empty name: got exit code %d, want %d", code, exitValidation)
+	}
+	if _, code := runAppAt(t, path, "rename", "Household", "2017"); code != 0 {
+		t.Fatalf("got exit code %d", code)
+	}
+	output, code := runAppAt(t, path, "--style", "ascii", "list")
+	if header := strings.SplitN(output, "\n", 2)[0]; code != 0 || !strings.HasPrefix(header, "Household 2017") {
+		t.Errorf("got header %q (exit code %d), want the new name", header, code)
+	}
+}
+
+func TestDeleteDryRun(t *testing.T) {
+	path := writeTestDatabase(t, testDatabase())
+	before, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, code := runAppAt(t, path, "delete", "--dry-run", "0")
+	if code != 0 || !strings.Contains(output, dryRunHeader) || !strings.Contains(output, "Salary") {
+		t.Errorf("got exit code %d, want a preview of the salary in\n%s", code, output)
+	}
+	if !strings.Contains(output, fmt.Sprintf(dryRunMessage, db.Value(-100000))) {
+		t.Errorf("missing the balance change in\n%s", output)
+	}
+	after, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Errorf("the database changed during a dry run")
+	}
+}
+
+func TestStoreDefaultType(t *testing.T) {
+	defer func(reader *bufio.Reader) { console = reader }(console)
+	path := writeTestDatabase(t, db.NewDatabase("test"))
+	// The type is left empty and defaults to withdraw, the optional fields stay empty.
+	console = bufio.NewReader(strings.NewReader("Coffee\n1.3.2016\n\n2.50\n\n\n\n"))
+	if _, code := runAppAt(t, path, "store"); code != 0 {
+		t.Fatalf("got exit code %d", code)
+	}
+	database, err := db.OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if database.Size() != 1 || database.Transactions[0].Type != db.Withdraw {
+		t.Errorf("got %+v, want a withdrawal", database.Transactions)
+	}
+}
+
+func TestReportGroupings(t *testing.T) {
+	march, april := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2016, 4, 1, 0, 0, 0, 0, time.UTC)
+	coffee := db.NewTransaction("Coffee", db.Withdraw, 250, march)
+	coffee.Category = "Food"
+	lunch := db.NewTransaction("Lunch", db.Withdraw, 1000, april)
+	lunch.Category = "Food"
+	ts := []db.Transaction{
+		db.NewTransaction("Salary", db.Deposit, 100000, march),
+		coffee,
+		lunch,
+		db.NewTransaction("Salary", db.Deposit, 100000, april),
+	}
+	tests := map[string]map[string]db.Value{
+		"name":     {"Salary": 200000, "Coffee": -250, "Lunch": -1000},
+		"category": {"": 200000, "Food": -1250},
+		"month":    {march.Format(reportMonthLayout): 99750, april.Format(reportMonthLayout): 99000},
+		"type":     {"deposit": 200000, "withdraw": -1250},
+	}
+	for grouping, want := range tests {
+		if got := db.GroupBy(ts, reportGroupings[grouping]); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", grouping, got, want)
+		}
+	}
+	path := writeTestDatabase(t, testDatabase())
+	for grouping, column := range map[string]string{"name": "Name", "category": "Category", "month": "Month", "type": "Type"} {
+		output, code := runAppAt(t, path, "--style", "markdown", "report", "--group-by", grouping)
+		if want := fmt.Sprintf("| %s | Net |\n", column); code != 0 || !strings.Contains(output, want) {
+			t.Errorf("%s: got %q (exit code %d), want the header %q", grouping, output, code, want)
+		}
+	}
+}
+
+func TestDeleteOutOfRange(t *testing.T) {
+	tests := []struct {
+		x, max int
+		valid  bool
+	}{
+		{0, 0, true},
+		{1, 0, false},
+		{-1, 0, false},
+		{0, -1, false},
+	}
+	for _, test := range tests {
+		if got := validIndex(test.x, test.max); got != test.valid {
+			t.Errorf("validIndex(%d, %d): got %v, want %v", test.x, test.max, got, test.valid)
+		}
+	}
+	for _, id := range []string{"1", "9999"} {
+		if code := runApp(t, "delete", "--yes", id); code != exitNotFound {
+			t.Errorf("delete %s: got exit code %d, want %d", id, code, exitNotFound)
+		}
+	}
+	if code := runApp(t, "delete", "--yes", "--", "-1"); code != exitNotFound {
+		t.Errorf("delete -1: got exit code %d, want %d", code, exitNotFound)
+	}
+}
+
+func TestColors(t *testing.T) {
+	defer func(style string, enabled bool) { tableStyle, colorEnabled = style, enabled }(tableStyle, colorEnabled)
+	tableStyle = tableStyleASCII
+	if got := colorize("x", colorRed, false); got != "x" {
+		t.Errorf("disabled: got %q", got)
+	}
+	if got := colorize("x", colorRed, true); got != colorRed+"x"+colorReset {
+		t.Errorf("enabled: got %q", got)
+	}
+	date := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+	entries := []tableEntry{
+		{0, db.NewTransaction("Salary", db.Deposit, 1000, date)},
+		{1, db.NewTransaction("Rent", db.Withdraw, 5000, date)},
+	}
+	for _, enabled := range []bool{false, true} {
+		colorEnabled = enabled
+		var buf bytes.Buffer
+		printTransactionTable(&buf, "test", entries, false)
+		lines := strings.Split(buf.String(), "\n")
+		if !enabled {
+			if strings.Contains(buf.String(), "\033[") {
+				t.Errorf("found color codes in\n%q", buf.String())
+			}
+			continue
+		}
+		if !strings.Contains(lines[1], colorGreen) || !strings.Contains(lines[2], colorRed) {
+			t.Errorf("want a green deposit and a red withdrawal in\n%q", buf.String())
+		}
+		if !strings.Contains(lines[2], colorBold) || strings.Contains(lines[1], colorBold) {
+			t.Errorf("want only the negative balance in bold in\n%q", buf.String())
+		}
+	}
+}
+
+// scriptedEditor writes a shell script running the sed expression on the edited file.
+func scriptedEditor(t *testing.T, expr string) string {
+	t.Helper()
+	if _, err := exec.LookPath("sed"); err != nil {
+		t.Skip("sed is not available")
+	}
+	path := filepath.Join(t.TempDir(), "editor.sh")
+	script := fmt.Sprintf("#!/bin/sh\nsed -e '%s' \"$1\" > \"$1.tmp\" && mv \"$1.tmp\" \"$1\"\n", expr)
+	if err := ioutil.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestEdit(t *testing.T) {
+	tests := []struct {
+		expr string
+		code int
+		want string
+	}{
+		{"s/Salary/Wage/", 0, "Wage"},
+		{"s/Salary//", exitValidation, "Salary"},
+		{"s/Salary/Salary/", 0, "Salary"},
+	}
+	for _, test := range tests {
+		path := writeTestDatabase(t, testDatabase())
+		t.Setenv("EDITOR", scriptedEditor(t, test.expr))
+		if _, code := runAppAt(t, path, "edit"); code != test.code {
+			t.Errorf("%s: got exit code %d, want %d", test.expr, code, test.code)
+		}
+		database, err := db.OpenFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := database.Transactions[0].Name; got != test.want {
+			t.Errorf("%s: got %s, want %s", test.expr, got, test.want)
+		}
+	}
+}
+
+func TestSince(t *testing.T) {
+	defer func(style string) { tableStyle = style }(tableStyle)
+	database := numberedDatabase(2)
+	database.Store(db.NewTransaction("Today", db.Withdraw, 100, time.Now()))
+	path := writeTestDatabase(t, database)
+	for _, command := range []string{"list", "filter"} {
+		output, code := runAppAt(t, path, "--style", "plain", command, "--since", "7d")
+		if got := strings.Join(rowNames(output), " "); code != 0 || got != "Today" {
+			t.Errorf("%s: got %q (exit code %d), want only today", command, got, code)
+		}
+	}
+}
+
+func TestReportTableStyles(t *testing.T) {
+	defer func(style string) { tableStyle = style }(tableStyle)
+	tests := []struct {
+		style string
+		want  string
+	}{
+		{tableStyleASCII, getTableHeader("test") + "\nSalary       1000.00\n"},
+		{tableStyleMarkdown, "**test**\n\n| Name | Net |\n|---|---|\n| Salary\\|Bonus | 1000.00 |\n"},
+		{tableStylePlain, "test\nSalary|Bonus\t1000.00\n"},
+		{tableStyleCompact, "Salary|Bonus 1000.00\n"},
+	}
+	for _, test := range tests {
+		tableStyle = test.style
+		var buf bytes.Buffer
+		table := newReportTable(&buf, "test", "Name", "Net")
+		table.row("Salary       1000.00", "Salary|Bonus", "1000.00")
+		if buf.String() != test.want {
+			t.Errorf("%s: got\n%q, want\n%q", test.style, buf.String(), test.want)
+		}
+	}
+}
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestTransactionTableGolden(t *testing.T) {
+	defer func(style, locale string, width int) {
+		tableStyle, displayLocale, tableWidth = style, locale, width
+	}(tableStyle, displayLocale, tableWidth)
+	displayLocale, tableWidth = "en", defaultTableWidth
+	date := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+	salary := db.NewTransaction("Salary", db.Deposit, 100000, date)
+	rent := db.NewTransaction("Rent | Flat", db.Withdraw, 50000, date.AddDate(0, 0, 1))
+	refund := db.NewTransaction("Refund", db.Withdraw, 1250, date.AddDate(0, 0, 2))
+	refund.Reversal = true
+	entries := []tableEntry{{0, salary}, {1, rent}, {2, refund}}
+	for _, style := range []string{tableStyleASCII, tableStyleMarkdown, tableStylePlain, tableStyleCompact} {
+		tableStyle = style
+		var buf bytes.Buffer
+		printTransactionTable(&buf, "test", entries, false)
+		path := filepath.Join("testdata", "table_"+style+".golden")
+		if *update {
+			if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		golden, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != string(golden) {
+			t.Errorf("%s: got\n%s\nwant\n%s\n(run go test -update if intended)", style, buf.String(), golden)
+		}
+	}
+}
+
+func TestOutputFile(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"list"}, "Salary"},
+		{[]string{"filter", "--name", "Salary"}, "Salary"},
+		{[]string{"report", "--group-by", "type"}, "deposit"},
+		{[]string{"summary"}, "2016"},
+		{[]string{"export"}, "Salary"},
+	}
+	for _, test := range tests {
+		// Parent directories are created as needed.
+		path := filepath.Join(t.TempDir(), "reports", test.args[0]+".txt")
+		output, code := runAppAt(t, writeTestDatabase(t, testDatabase()), append(test.args, "--output", path)...)
+		if code != 0 || output != "" {
+			t.Errorf("%s: got exit code %d and stdout %q, want nothing on stdout", test.args[0], code, output)
+			continue
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), test.want) {
+			t.Errorf("%s: missing %s in\n%s", test.args[0], test.want, data)
+		}
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{[]string{"count", "-q"}, 0},
+		{[]string{"filter", "--since", "garbage"}, exitValidation},
+		{[]string{"filter", "--since", "2d", "--from", "1.1.2016"}, exitValidation},
+		{[]string{"filter", "--from", "yesterday"}, exitValidation},
+		{[]string{"filter", "--max", "abc"}, exitValidation},
+		{[]string{"budget", "set", "Food"}, exitValidation},
+		{[]string{"budget", "set", "Food", "-5"}, exitValidation},
+		{[]string{"budget", "--month", "13.2016"}, exitValidation},
+		{[]string{"budget", "--month", "March"}, exitValidation},
+		{[]string{"filter", "--min", "ten"}, exitValidation},
+		{[]string{"filter", "--around", "ten"}, exitValidation},
+		{[]string{"filter", "--around", "10", "--tolerance", "abc"}, exitValidation},
+		{[]string{"filter", "--around", "10", "--tolerance", "1", "--output", os.DevNull}, 0},
+		{[]string{"filter", "--max", "0", "--output", os.DevNull}, 0},
+		{[]string{"show", "x"}, exitValidation},
+		{[]string{"delete", "x"}, exitValidation},
+		{[]string{"show", "7"}, exitNotFound},
+		{[]string{"report", "--group-by", "weekday"}, exitValidation},
+		{[]string{"--style", "fancy", "count"}, exitValidation},
+		{[]string{"--rounding", "up", "count"}, exitValidation},
+		{[]string{"--locale", "fr", "count"}, exitValidation},
+	}
+	for _, test := range tests {
+		if code := runApp(t, test.args...); code != test.code {
+			t.Errorf("%s: got exit code %d, want %d", strings.Join(test.args, " "), code, test.code)
+		}
+	}
+}
+
+func TestStoreCheckDupes(t *testing.T) {
+	defer func(reader *bufio.Reader) { console = reader }(console)
+	store := []string{"store", "--name", "Salary", "--amount", "1000", "--type", "deposit", "--date", "1.3.2016"}
+	tests := []struct {
+		flags  []string
+		answer string
+		size   int
+	}{
+		{nil, "", 2},
+		{[]string{"--check-dupes"}, "n\n", 1},
+		{[]string{"--check-dupes"}, "y\n", 2},
+		{[]string{"--check-dupes", "--force"}, "", 2},
+	}
+	for _, test := range tests {
+		console = bufio.NewReader(strings.NewReader(test.answer))
+		path := writeTestDatabase(t, testDatabase())
+		if _, code := runAppAt(t, path, append(store, test.flags...)...); code != 0 {
+			t.Errorf("%v: got exit code %d", test.flags, code)
+		}
+		database, err := db.OpenFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if database.Size() != test.size {
+			t.Errorf("%v answering %q: got %d transactions, want %d", test.flags, test.answer, database.Size(), test.size)
+		}
+	}
+}
+
+func TestShow(t *testing.T) {
+	database := testDatabase()
+	long := db.NewTransaction("A very long name that does not fit into the table", db.Withdraw, 1250, time.Date(2016, 3, 2, 12, 30, 0, 0, time.UTC))
+	long.Category = "Food"
+	long.Note = "Paid in cash"
+	database.Store(long)
+	path := writeTestDatabase(t, database)
+	output, code := runAppAt(t, path, "show", "1")
+	for _, want := range []string{"#1", long.Name, "withdraw", "12.50€", "02. March 2016 12:30", "Food", "Paid in cash"} {
+		if code != 0 || !strings.Contains(output, want) {
+			t.Errorf("missing %q in\n%s", want, output)
+		}
+	}
+	for _, id := range []string{"2", "-1"} {
+		if _, code := runAppAt(t, path, "show", "--", id); code != exitNotFound {
+			t.Errorf("show %s: got exit code %d, want %d", id, code, exitNotFound)
+		}
+	}
+}
+
+func TestMissingDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.trdb")
+	for _, command := range []string{"list", "filter", "balance"} {
+		if _, code := runAppAt(t, path, command); code != exitNotFound {
+			t.Errorf("%s: got exit code %d, want %d", command, code, exitNotFound)
+		}
+	}
+	_, err := db.OpenFile(path)
+	if got := databaseError(err); got == nil || got.Error() != noDatabaseMessage {
+		t.Errorf("got %v, want %q", got, noDatabaseMessage)
+	}
+}
+
+func TestVerifyExitCode(t *testing.T) {
+	if code := runApp(t, "verify"); code != 0 {
+		t.Errorf("valid database: got exit code %d, want 0", code)
+	}
+	database := testDatabase()
+	database.Transactions = append(database.Transactions, db.Transaction{Name: "Broken", Type: db.Deposit, Amount: -1})
+	if _, code := runAppAt(t, writeTestDatabase(t, database), "verify"); code == 0 {
+		t.Error("invalid database: got exit code 0")
+	}
+}
+
+func TestStoreNonInteractive(t *testing.T) {
+	defer func(reader *bufio.Reader) { console = reader }(console)
+	// Prompts for the missing amount only.
+	console = bufio.NewReader(strings.NewReader("12,50\n"))
+	path := writeTestDatabase(t, db.NewDatabase("test"))
+	if _, code := runAppAt(t, path, "store", "--name", "Lunch", "--type", "withdraw", "--date", "2.3.2016 12:30"); code != 0 {
+		t.Fatalf("got exit code %d", code)
+	}
+	console = bufio.NewReader(strings.NewReader(""))
+	if _, code := runAppAt(t, path, "store", "--name", "Salary", "--type", "deposit", "--amount", "1000", "--date", "1.3.2016"); code != 0 {
+		t.Fatalf("got exit code %d", code)
+	}
+	if _, code := runAppAt(t, path, "store", "--name", "Salary", "--type", "deposit", "--amount", "1000", "--date", "yesterday"); code != exitValidation {
+		t.Errorf("invalid date: got exit code %d, want %d", code, exitValidation)
+	}
+	if _, code := runAppAt(t, path, "store", "--name", "Salary", "--type", "deposit", "--amount", "ten", "--date", "1.3.2016"); code != exitValidation {
+		t.Errorf("invalid amount: got exit code %d, want %d", code, exitValidation)
+	}
+	database, err := db.OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []db.Transaction{
+		db.NewTransaction("Lunch", db.Withdraw, 1250, time.Date(2016, 3, 2, 12, 30, 0, 0, time.UTC)),
+		db.NewTransaction("Salary", db.Deposit, 100000, time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)),
+	}
+	if database.Size() != len(want) {
+		t.Fatalf("got %d transactions, want %d", database.Size(), len(want))
+	}
+	for i, w := range want {
+		got := database.Transactions[i]
+		if got.Name != w.Name || got.Type != w.Type || got.Amount != w.Amount || !got.Date.Equal(w.Date) {
+			t.Errorf("got %+v, want %+v", got, w)
+		}
+	}
+}
+
+func TestFilterDirection(t *testing.T) {
+	database := testDatabase()
+	database.Store(db.NewTransaction("Rent", db.Withdraw, 50000, time.Date(2016, 3, 2, 0, 0, 0, 0, time.UTC)))
+	path := writeTestDatabase(t, database)
+	filter := func(args ...string) (string, int) {
+		output, code := runAppAt(t, path, append([]string{"--style", "plain", "--no-header", "filter"}, args...)...)
+		return strings.Join(rowNames(output), " "), code
+	}
+	tests := []struct {
+		direction, typ string
+		want           string
+	}{
+		{"in", "deposit", "Salary"},
+		{"OUT", "withdraw", "Rent"},
+	}
+	for _, test := range tests {
+		byDirection, code := filter("--direction", test.direction)
+		byType, _ := filter("--type", test.typ)
+		if code != 0 || byDirection != test.want || byDirection != byType {
+			t.Errorf("--direction %s: got %q (exit code %d), want %q like --type %s (%q)", test.direction, byDirection, code, test.want, test.typ, byType)
+		}
+	}
+	if got, code := filter("--direction", "in", "--type", "dp"); code != 0 || got != "Salary" {
+		t.Errorf("agreeing --direction and --type: got %q (exit code %d)", got, code)
+	}
+	for _, args := range [][]string{{"--direction", "sideways"}, {"--direction", "in", "--type", "wd"}} {
+		if _, code := filter(args...); code != exitValidation {
+			t.Errorf("%s: got exit code %d, want %d", strings.Join(args, " "), code, exitValidation)
+		}
+	}
+}
+
+func TestStoreNoFuture(t *testing.T) {
+	store := []string{"store", "--name", "Typo", "--type", "wd", "--amount", "1", "--date", "1.3.2206"}
+	tests := []struct {
+		flags []string
+		code  int
+		size  int
+	}{
+		{[]string{"--no-future"}, exitValidation, 1},
+		{nil, 0, 2},
+	}
+	for _, test := range tests {
+		path := writeTestDatabase(t, testDatabase())
+		if _, code := runAppAt(t, path, append(store, test.flags...)...); code != test.code {
+			t.Errorf("%v: got exit code %d, want %d", test.flags, code, test.code)
+		}
+		database, err := db.OpenFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if database.Size() != test.size {
+			t.Errorf("%v: got %d transactions, want %d", test.flags, database.Size(), test.size)
+		}
+	}
+}
+
+func TestIsFutureDate(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	// Late evening in New York is already the next day in UTC.
+	now := time.Date(2026, 10, 16, 22, 0, 0, 0, newYork)
+	tests := []struct {
+		date   time.Time
+		future bool
+	}{
+		{time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), false},
+		{time.Date(2026, 10, 16, 23, 59, 0, 0, time.UTC), false},
+		{time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), true},
+		{now, false},
+	}
+	for _, test := range tests {
+		if got := isFutureDate(test.date, now); got != test.future {
+			t.Errorf("isFutureDate(%v): got %v, want %v", test.date, got, test.future)
+		}
+	}
+}
+
+func TestMonthName(t *testing.T) {
+	tests := []struct {
+		month  time.Month
+		locale string
+		want   string
+	}{
+		{time.March, "en", "March"},
+		{time.March, "de", "März"},
+		{time.December, "de", "Dezember"},
+		{time.May, "", "May"},
+		{time.May, "fr", "May"},
+	}
+	for _, test := range tests {
+		if got := monthName(test.month, test.locale); got != test.want {
+			t.Errorf("monthName(%v, %q): got %s, want %s", test.month, test.locale, got, test.want)
+		}
+	}
+}
+
+func TestLocaleFromEnv(t *testing.T) {
+	tests := []struct {
+		all, time, lang string
+		want            string
+	}{
+		{"", "", "de_DE.UTF-8", "de"},
+		{"", "en_US.UTF-8", "de_DE.UTF-8", "en"},
+		{"DE", "en_US", "", "de"},
+		{"", "", "", ""},
+	}
+	for _, test := range tests {
+		t.Setenv("LC_ALL", test.all)
+		t.Setenv("LC_TIME", test.time)
+		t.Setenv("LANG", test.lang)
+		if got := localeFromEnv(); got != test.want {
+			t.Errorf("LC_ALL=%q LC_TIME=%q LANG=%q: got %q, want %q", test.all, test.time, test.lang, got, test.want)
+		}
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	defer func(reader *bufio.Reader) { console = reader }(console)
+	tests := []struct {
+		input string
+		skip  bool
+		want  bool
+	}{
+		{"y\n", false, true},
+		{"YES\n", false, true},
+		{"n\n", false, false},
+		{"\n", false, false},
+		{"", false, false},
+		{"n\n", true, true},
+	}
+	for _, test := range tests {
+		console = bufio.NewReader(strings.NewReader(test.input))
+		var got bool
+		captureStdout(t, func() { got = confirm("Sure? ", test.skip) })
+		if got != test.want {
+			t.Errorf("%q, skip %v: got %v, want %v", test.input, test.skip, got, test.want)
+		}
+	}
+	// With --yes, destructive commands do not read the answer from the closed input.
+	console = bufio.NewReader(strings.NewReader(""))
+	for _, args := range [][]string{{"delete", "--yes", "0"}, {"clear", "-y"}} {
+		path := writeTestDatabase(t, testDatabase())
+		if _, code := runAppAt(t, path, args...); code != 0 {
+			t.Errorf("%s: got exit code %d", strings.Join(args, " "), code)
+		}
+		if database, err := db.OpenFile(path); err != nil || database.Size() != 0 {
+			t.Errorf("%s: got %d transactions, %v, want none", strings.Join(args, " "), database.Size(), err)
+		}
+	}
+}
+
+func TestInitConfirmation(t *testing.T) {
+	defer func(reader *bufio.Reader) { console = reader }(console)
+	tests := []struct {
+		input string
+		want  string
+		size  int
+	}{
+		{"y\nFresh\n", "Fresh", 0},
+		{"yes\nFresh\n", "Fresh", 0},
+		{"n\n", "test", 1},
+		{"\n", "test", 1},
+	}
+	for _, test := range tests {
+		path := writeTestDatabase(t, testDatabase())
+		console = bufio.NewReader(strings.NewReader(test.input))
+		if _, code := runAppAt(t, path, "init"); code != 0 {
+			t.Errorf("%q: got exit code %d", test.input, code)
+		}
+		database, err := db.OpenFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if database.Name != test.want || database.Size() != test.size {
+			t.Errorf("%q: got %q with %d transactions, want %q with %d", test.input, database.Name, database.Size(), test.want, test.size)
+		}
+	}
+}
+
+func TestRawAmounts(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"balance", "--raw"}, "100000\n"},
+		{[]string{"balance", "--decimal"}, "1000.00\n"},
+		{[]string{"summary", "--raw"}, "1\t100000\t0\t100000\n"},
+		{[]string{"summary", "--decimal"}, "1\t1000.00\t0.00\t1000.00\n"},
+	}
+	for _, test := range tests {
+		got, code := runAppAt(t, writeTestDatabase(t, testDatabase()), test.args...)
+		if code != 0 || got != test.want {
+			t.Errorf("%s: got %q (exit code %d), want %q", strings.Join(test.args, " "), got, code, test.want)
+		}
+	}
+	if code := runApp(t, "balance", "--raw", "--per-day"); code != exitValidation {
+		t.Errorf("balance --raw --per-day: got exit code %d, want %d", code, exitValidation)
+	}
+}
+
+func TestParseTransactionDate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+		ok   bool
+	}{
+		{"1.3.2016", time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC), true},
+		{"01.03.2016", time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC), true},
+		{" 1.3.2016 ", time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC), true},
+		{"1.3.2016 12:30", time.Date(2016, 3, 1, 12, 30, 0, 0, time.UTC), true},
+		{"1.3.2016   08:05", time.Date(2016, 3, 1, 8, 5, 0, 0, time.UTC), true},
+		{"2016-03-01", time.Time{}, false},
+		{"1.3.2016 25:00", time.Time{}, false},
+		{"yesterday", time.Time{}, false},
+	}
+	for _, test := range tests {
+		got, err := parseTransactionDate(test.in)
+		if (err == nil) != test.ok || test.ok && !got.Equal(test.want) {
+			t.Errorf("%q: got %v, %v, want %v", test.in, got, err, test.want)
+		}
+	}
+}
+
+func TestFirst(t *testing.T) {
+	database := db.NewDatabase("test")
+	path := writeTestDatabase(t, database)
+	if output, code := runAppAt(t, path, "first"); code != 0 || output != fmt.Sprintf(emptyDatabaseMessage, "test") {
+		t.Errorf("empty database: got %q (exit code %d)", output, code)
+	}
+	// Transactions of the same day are tie-broken by ID.
+	date := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+	database.Store(db.NewTransaction("Later", db.Withdraw, 100, date.AddDate(0, 0, 1)))
+	database.Store(db.NewTransaction("Coffee", db.Withdraw, 250, date))
+	database.Store(db.NewTransaction("Lunch", db.Withdraw, 1250, date))
+	path = writeTestDatabase(t, database)
+	for _, command := range []string{"first", "oldest"} {
+		output, code := runAppAt(t, path, command)
+		if code != 0 || !strings.Contains(output, "#1\n") || !strings.Contains(output, "Coffee") {
+			t.Errorf("%s: got exit code %d, want #1 Coffee in\n%s", command, code, output)
+		}
+	}
+}
+
+func TestLastBackdated(t *testing.T) {
+	path := writeTestDatabase(t, testDatabase())
+	store := func(name, date string) {
+		t.Helper()
+		if _, code := runAppAt(t, path, "store", "--name", name, "--type", "wd", "--amount", "1", "--date", date); code != 0 {
+			t.Fatalf("store %s: got exit code %d", name, code)
+		}
+	}
+	store("Rent", "15.3.2016")
+	// Stored last, but dated before the rent.
+	store("Forgotten", "10.3.2016")
+	for _, command := range []string{"last", "latest"} {
+		output, code := runAppAt(t, path, command)
+		if code != 0 || !strings.Contains(output, "#1\n") || !strings.Contains(output, "Rent") || strings.Contains(output, "Forgotten") {
+			t.Errorf("%s: got exit code %d, want #1 Rent in\n%s", command, code, output)
+		}
+	}
+}
+
+func TestFilterNote(t *testing.T) {
+	database := testDatabase()
+	date := time.Date(2016, 3, 2, 0, 0, 0, 0, time.UTC)
+	for _, entry := range []struct{ name, note string }{
+		{"Lunch", "Lunch with Alice"},
+		{"Gift", "Birthday gift for ALICE"},
+		{"Dinner", "Team dinner"},
+	} {
+		transact := db.NewTransaction(entry.name, db.Withdraw, 1000, date)
+		transact.Note = entry.note
+		database.Store(transact)
+	}
+	path := writeTestDatabase(t, database)
+	tests := []struct {
+		note string
+		want string
+	}{
+		{"alice", "Lunch Gift"},
+		{"DINNER", "Dinner"},
+		{"bob", ""},
+		// Without --note, transactions without a note are listed as well.
+		{"", "Salary Lunch Gift Dinner"},
+	}
+	for _, test := range tests {
+		output, code := runAppAt(t, path, "--style", "plain", "--no-header", "filter", "--note", test.note)
+		if got := strings.Join(rowNames(output), " "); code != 0 || got != test.want {
+			t.Errorf("--note %q: got %q (exit code %d), want %q", test.note, got, code, test.want)
+		}
+	}
+}
+
+func TestTagsStats(t *testing.T) {
+	database := testDatabase()
+	date := time.Date(2016, 3, 2, 0, 0, 0, 0, time.UTC)
+	for _, category := range []string{"Home", "Food", "Fun"} {
+		transact := db.NewTransaction(category, db.Withdraw, 100, date)
+		transact.Category = category
+		database.Store(transact)
+	}
+	output, code := runAppAt(t, writeTestDatabase(t, database), "--style", "plain", "--no-header", "tags", "stats")
+	// Deposits are left out and the rounded shares still sum up to 100%.
+	want := "Food\t1.00€\t33.4%\nFun\t1.00€\t33.3%\nHome\t1.00€\t33.3%\n"
+	if code != 0 || output != want {
+		t.Errorf("got %q (exit code %d), want %q", output, code, want)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	tests := []struct {
+		lines []string
+		code  int
+		size  int
+	}{
+		{[]string{"# name,type,amount,date", "Coffee,wd,2.50,2.3.2016", "", "Salary,dp,1000,1.4.2016 09:00", "\"Rent, March\",withdraw,500"}, 0, 4},
+		// A single invalid line stores nothing.
+		{[]string{"Coffee,wd,2.50,2.3.2016", "Lunch,wd,abc", "Dinner,wd,10,yesterday"}, exitValidation, 1},
+	}
+	for _, test := range tests {
+		path := writeTestDatabase(t, testDatabase())
+		batch := filepath.Join(t.TempDir(), "batch.txt")
+		if err := ioutil.WriteFile(batch, []byte(strings.Join(test.lines, "\n")), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, code := runAppAt(t, path, "batch", batch); code != test.code {
+			t.Errorf("%q: got exit code %d, want %d", test.lines, code, test.code)
+		}
+		database, err := db.OpenFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if database.Size() != test.size {
+			t.Errorf("%q: got %d transactions, want %d", test.lines, database.Size(), test.size)
+		}
+	}
+}
+
+func TestParseBatchLine(t *testing.T) {
+	for _, line := range []string{"Coffee,wd", "Coffee,wd,2.50,2.3.2016,extra", "Coffee,wd,0", "Coffee,wd,abc", "Coffee,wd,2.50,2016-03-02", ",wd,2.50", "Coffee,sideways,2.50"} {
+		if _, err := parseBatchLine(line); err == nil {
+			t.Errorf("%q: got no error", line)
+		}
+	}
+	transact, err := parseBatchLine(" Coffee , - , 2.50 , 2.3.2016 12:30 ")
+	if err != nil || transact.Name != "Coffee" || transact.Type != db.Withdraw || transact.Amount != 250 || transact.Date.Hour() != 12 {
+		t.Errorf("got %+v, %v", transact, err)
+	}
+}
+
+func TestTransferAmount(t *testing.T) {
+	// The amount is read in the book currency of the source, which has no cents here.
+	db.RegisterCurrency(db.Currency{Name: "TransferYen", Format: "%d", Ratio: 1, Symbol: "¥"})
+	yen := db.NewDatabase("yen")
+	yen.Currency = "TransferYen"
+	src, dst, euro := writeTestDatabase(t, yen), writeTestDatabase(t, yen), writeTestDatabase(t, testDatabase())
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{[]string{"transfer", "--amount", "ten", src, dst}, exitValidation},
+		{[]string{"transfer", "--amount", "500", src, euro}, exitValidation},
+		{[]string{"transfer", "--amount", "500", src, dst}, 0},
+	}
+	for _, test := range tests {
+		if _, code := runAppAt(t, src, test.args...); code != test.code {
+			t.Errorf("%s: got exit code %d, want %d", strings.Join(test.args, " "), code, test.code)
+		}
+	}
+	database, err := db.OpenFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := database.Balance(); got != 500 {
+		t.Errorf("got balance %d, want 500", int(got))
+	}
+}
+
+func TestReportIncludeEmpty(t *testing.T) {
+	database := testDatabase()
+	rent := db.NewTransaction("Rent", db.Withdraw, 50000, time.Date(2016, 3, 2, 0, 0, 0, 0, time.UTC))
+	rent.Category = "Home"
+	database.Store(rent)
+	coffee := db.NewTransaction("Coffee", db.Withdraw, 250, time.Now())
+	coffee.Category = "Food"
+	database.Store(coffee)
+	path := writeTestDatabase(t, database)
+	if _, code := runAppAt(t, path, "budget", "set", "Travel", "100"); code != 0 {
+		t.Fatalf("budget set: got exit code %d", code)
+	}
+	report := []string{"--style", "plain", "--no-header", "report", "--group-by", "category", "--since", "30d"}
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, "Food\t-2.50€\n"},
+		// Categories of the whole history and the budget show up as zero.
+		{[]string{"--include-empty"}, fmt.Sprintf("%s\t0.00€\nFood\t-2.50€\nHome\t0.00€\nTravel\t0.00€\n", reportEmptyKey)},
+	}
+	for _, test := range tests {
+		output, code := runAppAt(t, path, append(report, test.args...)...)
+		if code != 0 || output != test.want {
+			t.Errorf("%v: got %q (exit code %d), want %q", test.args, output, code, test.want)
+		}
+	}
+}
+
+func TestNoHeader(t *testing.T) {
+	path := writeTestDatabase(t, numberedDatabase(3))
+	for _, style := range []string{tableStyleASCII, tableStyleMarkdown, tableStylePlain, tableStyleCompact} {
+		full, _ := runAppAt(t, path, "--style", style, "list")
+		rows, code := runAppAt(t, path, "--style", style, "--no-header", "list")
+		lines := strings.Split(strings.TrimSuffix(rows, "\n"), "\n")
+		if code != 0 || len(lines) != 3 {
+			t.Errorf("%s: got %d lines (exit code %d), want the 3 rows in\n%s", style, len(lines), code, rows)
+			continue
+		}
+		// Only the rows of the full table are left.
+		for _, line := range lines {
+			if !strings.Contains(full, line+"\n") {
+				t.Errorf("%s: line %q is not a row of\n%s", style, line, full)
+			}
+		}
+		if strings.Contains(rows, "test") || strings.Contains(rows, tableHeaderSymbol) || strings.Contains(rows, "---") {
+			t.Errorf("%s: found header or total bytes in\n%s", style, rows)
+		}
+	}
+}
+
+func TestInfo(t *testing.T) {
+	defer func(locale string) { displayLocale = locale }(displayLocale)
+	database := testDatabase()
+	receipt := db.NewTransaction("Market", db.Withdraw, 3000, time.Date(2016, 3, 5, 18, 0, 0, 0, time.UTC))
+	receipt.Category = "Food"
+	receipt.Splits = []db.Split{{Category: "Food", Amount: 2000}, {Category: "Household", Amount: 1000}}
+	database.Store(receipt)
+	path := writeTestDatabase(t, database)
+	stat, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, code := runAppAt(t, path, "--locale", "en", "info")
+	for _, field := range [][2]string{
+		{"Name:", "test"},
+		{"Path:", path},
+		{"Transactions:", "2"},
+		{"Currency:", "Euro"},
+		{"Balance:", "970.00€"},
+		{"Date range:", "01. March 2016 00:00 - 05. March 2016 18:00"},
+		{"Categories:", "2 (Food, Household)"},
+		{"File size:", fmt.Sprintf("%d bytes", stat.Size())},
+	} {
+		if line := fmt.Sprintf(infoFieldFormat, field[0], field[1]); code != 0 || !strings.Contains(output, line) {
+			t.Errorf("missing %q in\n%s", line, output)
+		}
+	}
+}
+
+func TestSignedAmount(t *testing.T) {
+	date := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+	refund := db.NewTransaction("Refund", db.Withdraw, 250, date)
+	refund.Reversal = true
+	correction := db.NewTransaction("Correction", db.Deposit, 1250, date)
+	correction.Reversal = true
+	dollars := db.NewTransaction("Hotel", db.Withdraw, 10000, date)
+	dollars.Currency = db.USDollar.Name
+	tests := []struct {
+		transact db.Transaction
+		want     string
+	}{
+		{db.NewTransaction("Salary", db.Deposit, 1250, date), "+12.50€"},
+		{db.NewTransaction("Coffee", db.Withdraw, 1250, date), "-12.50€"},
+		{refund, "+2.50€"},
+		{correction, "-12.50€"},
+		{dollars, "-$100.00"},
+	}
+	for _, test := range tests {
+		if got := signedAmount(test.transact); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.transact.Name, got, test.want)
+		}
+	}
+	path := writeTestDatabase(t, testDatabase())
+	for _, test := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--style", "ascii", "--signed", "list"}, " +1000.00€ "},
+		{[]string{"--style", "ascii", "list"}, " 1000.00€ "},
+	} {
+		output, code := runAppAt(t, path, test.args...)
+		if code != 0 || !strings.Contains(output, test.want) {
+			t.Errorf("%s: missing %q in\n%s", strings.Join(test.args, " "), test.want, output)
+		}
+	}
+}
+
+func TestImportCurrencyMismatch(t *testing.T) {
+	tests := []struct {
+		csv      string
+		args     []string
+		code     int
+		currency string
+	}{
+		{"name,amount,date\nCoffee,-2.50,2016-03-02\n", nil, 0, "Euro"},
+		{"name,amount,date,currency\nCoffee,-2.50,2016-03-02,USD\n", nil, exitValidation, ""},
+		{"name,amount,date,currency\nCoffee,-2.50,2016-03-02,USD\n", []string{"--assume-currency", "usd"}, 0, "USD"},
+		{"name,amount,date\nCoffee,-2.50,2016-03-02\n", []string{"--assume-currency", "xyz"}, exitValidation, ""},
+	}
+	for _, test := range tests {
+		path := writeTestDatabase(t, testDatabase())
+		statement := filepath.Join(t.TempDir(), "statement.csv")
+		if err := ioutil.WriteFile(statement, []byte(test.csv), 0644); err != nil {
+			t.Fatal(err)
+		}
+		args := append([]string{"import", "--format", "csv"}, test.args...)
+		if _, code := runAppAt(t, path, append(args, statement)...); code != test.code {
+			t.Errorf("%q %v: got exit code %d, want %d", test.csv, test.args, code, test.code)
+		}
+		database, err := db.OpenFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.currency == "" {
+			if database.Size() != 1 {
+				t.Errorf("%q %v: got %d transactions, want nothing imported", test.csv, test.args, database.Size())
+			}
+			continue
+		}
+		if database.Size() != 2 || database.Transactions[1].CurrencyOf().Name != test.currency {
+			t.Errorf("%q %v: got %+v, want the coffee in %s", test.csv, test.args, database.Transactions, test.currency)
+		}
+	}
+}
+
+func TestExportCSVLocale(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"export", "--format", "csv"}, "1000.00"},
+		{[]string{"--locale", "de", "export", "--format", "csv"}, "1000,00"},
+		{[]string{"--locale", "de", "export", "--format", "csv", "--csv-locale", "en"}, "1000.00"},
+		{[]string{"export", "--format", "csv", "--csv-locale", "de"}, "1000,00"},
+	}
+	for _, test := range tests {
+		path := filepath.Join(t.TempDir(), "export.csv")
+		if code := runApp(t, append(test.args, "--output", path)...); code != 0 {
+			t.Errorf("%s: got exit code %d", strings.Join(test.args, " "), code)
+			continue
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), test.want) {
+			t.Errorf("%s: missing %s in\n%s", strings.Join(test.args, " "), test.want, data)
+		}
+	}
+}
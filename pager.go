@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	pagerPrompt = "-- more (Enter to continue, q to quit) --"
+	// Moves the cursor onto the answered prompt and clears it.
+	pagerClearSequence = "\033[1A\033[2K"
+)
+
+// pagedWriter pauses after every page of lines until the user continues.
+// Everything written after the user quits is discarded.
+type pagedWriter struct {
+	out      io.Writer
+	pageSize int
+	lines    int
+	quit     bool
+}
+
+// newPager pages output to stdout if it is an interactive terminal and the page size is positive.
+func newPager(out io.Writer, pageSize int) io.Writer {
+	if pageSize <= 0 || !isTerminal(os.Stdout) || !isTerminal(os.Stdin) {
+		return out
+	}
+	return &pagedWriter{out: out, pageSize: pageSize}
+}
+
+func (w *pagedWriter) Write(p []byte) (int, error) {
+	for rest := p; len(rest) > 0 && !w.quit; {
+		line := rest
+		if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+			line = rest[:i+1]
+		}
+		if w.lines == w.pageSize {
+			w.wait()
+			if w.quit {
+				break
+			}
+		}
+		if _, err := w.out.Write(line); err != nil {
+			return 0, err
+		}
+		if line[len(line)-1] == '\n' {
+			w.lines++
+		}
+		rest = rest[len(line):]
+	}
+	return len(p), nil
+}
+
+// wait shows the prompt and reads the answer, quitting on q or the end of input.
+func (w *pagedWriter) wait() {
+	fmt.Fprint(w.out, pagerPrompt)
+	answer, err := getInput()
+	fmt.Fprint(w.out, pagerClearSequence)
+	w.quit = err != nil || strings.EqualFold(answer, "q")
+	w.lines = 0
+}
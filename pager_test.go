@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPagedWriter(t *testing.T) {
+	defer func(reader *bufio.Reader) { console = reader }(console)
+	page := func(from, to int) string {
+		var s string
+		for i := from; i <= to; i++ {
+			s += fmt.Sprintf("line %d\n", i)
+		}
+		return s
+	}
+	prompt := pagerPrompt + pagerClearSequence
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"\n\n", page(1, 3) + prompt + page(4, 6) + prompt + page(7, 8)},
+		{"\nq\n", page(1, 3) + prompt + page(4, 6) + prompt},
+		{"Q\n", page(1, 3) + prompt},
+		// The end of input quits like q.
+		{"", page(1, 3) + prompt},
+	}
+	for _, test := range tests {
+		console = bufio.NewReader(strings.NewReader(test.input))
+		var out bytes.Buffer
+		w := &pagedWriter{out: &out, pageSize: 3}
+		// Lines split across writes are counted once.
+		data := page(1, 8)
+		for _, chunk := range []string{data[:10], data[10:]} {
+			if n, err := w.Write([]byte(chunk)); err != nil || n != len(chunk) {
+				t.Fatalf("%q: got %d, %v", test.input, n, err)
+			}
+		}
+		if out.String() != test.want {
+			t.Errorf("%q: got %q, want %q", test.input, out.String(), test.want)
+		}
+	}
+}
+
+func TestPagerNonInteractive(t *testing.T) {
+	var out bytes.Buffer
+	if w := newPager(&out, 0); w != &out {
+		t.Errorf("page size 0: got a pager")
+	}
+	if isTerminal(os.Stdout) && isTerminal(os.Stdin) {
+		t.Skip("running in a terminal")
+	}
+	if w := newPager(&out, 3); w != &out {
+		t.Errorf("page size 3: got a pager without a terminal")
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lnsp/transaction/db"
+	"github.com/urfave/cli"
+)
+
+const (
+	transactionsRoute = "/transactions"
+	balanceRoute      = "/balance"
+
+	serveStartMessage = "Serving the database on %s.\n"
+)
+
+// server exposes the database over HTTP.
+type server struct {
+	// Guards every database access.
+	mu sync.Mutex
+}
+
+type transactionEntry struct {
+	ID          int            `json:"id"`
+	Transaction db.Transaction `json:"transaction"`
+}
+
+type balanceEntry struct {
+	Balance   db.Value `json:"balance"`
+	Formatted string   `json:"formatted"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func (s *server) handleTransactions(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.Method {
+	case http.MethodGet:
+		database, err := db.Open()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		entries := make([]transactionEntry, database.Size())
+		for id, transact := range database.Transactions {
+			entries[id] = transactionEntry{id, transact}
+		}
+		writeJSON(w, http.StatusOK, entries)
+	case http.MethodPost:
+		var transact db.Transaction
+		if err := json.NewDecoder(r.Body).Decode(&transact); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if err := transact.Validate(); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if err := db.Store(transact); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, transact)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *server) handleTransaction(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	ID, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, transactionsRoute+"/"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := db.Delete(ID); errors.Is(err, db.ErrNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *server) handleBalance(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	database, err := db.Open()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	book := database.BookCurrency()
+	converted, err := db.ConvertDatabase(database, book)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	balance := converted.Balance()
+	writeJSON(w, http.StatusOK, balanceEntry{balance, balance.Format(book)})
+}
+
+func serveAction(c *cli.Context) error {
+	s := &server{}
+	mux := http.NewServeMux()
+	mux.HandleFunc(transactionsRoute, s.handleTransactions)
+	mux.HandleFunc(transactionsRoute+"/", s.handleTransaction)
+	mux.HandleFunc(balanceRoute, s.handleBalance)
+	fmt.Printf(serveStartMessage, c.String("addr"))
+	return http.ListenAndServe(c.String("addr"), mux)
+}
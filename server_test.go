@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lnsp/transaction/db"
+)
+
+func TestServerTransactions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "serve.trdb")
+	if err := db.WriteFile(path, db.NewDatabase("test")); err != nil {
+		t.Fatal(err)
+	}
+	db.SetPath(path)
+	defer db.SetPath("")
+	s := &server{}
+	tests := []struct {
+		method, target, body string
+		handler              http.HandlerFunc
+		status               int
+	}{
+		{http.MethodPost, "/transactions", `{"name":"Coffee","amount":250,"type":"withdraw","date":"2016-03-01T00:00:00Z"}`, s.handleTransactions, http.StatusCreated},
+		{http.MethodPost, "/transactions", `{"name":"","amount":250,"type":"withdraw","date":"2016-03-01T00:00:00Z"}`, s.handleTransactions, http.StatusBadRequest},
+		{http.MethodPost, "/transactions", `{"name":"Coffee","amount":250,"type":"steal","date":"2016-03-01T00:00:00Z"}`, s.handleTransactions, http.StatusBadRequest},
+		{http.MethodPost, "/transactions", `{`, s.handleTransactions, http.StatusBadRequest},
+		{http.MethodDelete, "/transactions/5", "", s.handleTransaction, http.StatusNotFound},
+		{http.MethodDelete, "/transactions/0", "", s.handleTransaction, http.StatusNoContent},
+	}
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		test.handler(rec, httptest.NewRequest(test.method, test.target, strings.NewReader(test.body)))
+		if rec.Code != test.status {
+			t.Errorf("%s %s %s: got status %d, want %d", test.method, test.target, test.body, rec.Code, test.status)
+		}
+	}
+}
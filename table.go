@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/lnsp/transaction/db"
+)
+
+const (
+	tableStyleASCII    = "ascii"
+	tableStyleMarkdown = "markdown"
+	tableStylePlain    = "plain"
+	tableStyleCompact  = "compact"
+)
+
+// tableRenderer prints a table of transactions row by row.
+// The amount width is the widest amount or balance in the table.
+type tableRenderer interface {
+	header(title string, amountWidth int)
+	row(id int, transact db.Transaction, amount, balance string, overdrawn bool)
+	footer(total string, overdrawn bool)
+}
+
+// newTableRenderer selects the renderer for the given style.
+func newTableRenderer(style string, out io.Writer) tableRenderer {
+	switch style {
+	case tableStyleMarkdown:
+		return &markdownRenderer{out: out}
+	case tableStylePlain:
+		return &plainRenderer{out: out}
+	case tableStyleCompact:
+		return &compactRenderer{out: out}
+	default:
+		return &asciiRenderer{out: out}
+	}
+}
+
+// mutableWriter discards everything written while muted.
+type mutableWriter struct {
+	out   io.Writer
+	muted bool
+}
+
+func (w *mutableWriter) Write(p []byte) (int, error) {
+	if w.muted {
+		return len(p), nil
+	}
+	return w.out.Write(p)
+}
+
+// rowsOnlyRenderer hides the header and footer of the renderer of a style.
+// The header is still rendered into the void, as it prepares the column widths.
+type rowsOnlyRenderer struct {
+	tableRenderer
+	out *mutableWriter
+}
+
+func newRowsOnlyRenderer(style string, out io.Writer) tableRenderer {
+	w := &mutableWriter{out: out}
+	return &rowsOnlyRenderer{newTableRenderer(style, w), w}
+}
+
+func (r *rowsOnlyRenderer) header(title string, amountWidth int) {
+	r.out.muted = true
+	r.tableRenderer.header(title, amountWidth)
+	r.out.muted = false
+}
+
+func (r *rowsOnlyRenderer) footer(total string, overdrawn bool) {}
+
+// validTableStyle checks if a renderer exists for the style.
+func validTableStyle(style string) bool {
+	return style == tableStyleASCII || style == tableStyleMarkdown || style == tableStylePlain || style == tableStyleCompact
+}
+
+// asciiRenderer draws fixed-width columns below a header line.
+type asciiRenderer struct {
+	out                               io.Writer
+	amountWidth, dateWidth, nameWidth int
+}
+
+func (r *asciiRenderer) header(title string, amountWidth int) {
+	r.amountWidth = amountWidth
+	r.dateWidth, r.nameWidth = tableColumns(amountWidth)
+	fmt.Fprintln(r.out, getTableHeader(title))
+}
+
+func (r *asciiRenderer) row(id int, transact db.Transaction, amount, balance string, overdrawn bool) {
+	idString := "[#" + strconv.Itoa(id) + "]"
+	amount = colorize(padLeft(amount, r.amountWidth), amountColor(transact), colorEnabled)
+	balance = padLeft(balance, r.amountWidth)
+	if overdrawn {
+		balance = colorize(balance, colorBold, colorEnabled)
+	}
+	fmt.Fprintf(r.out, "%6s  On %s %s :: %-8s %s %s\n", idString, limitString(formatTime(transact.Date), r.dateWidth), limitString(transact.Name, r.nameWidth), transact.Type, amount, balance)
+}
+
+func (r *asciiRenderer) footer(total string, overdrawn bool) {
+	indent := strings.Repeat(" ", tableFixedWidth-1+r.dateWidth+r.nameWidth)
+	total = padLeft(total, r.amountWidth)
+	if overdrawn {
+		total = colorize(total, colorBold, colorEnabled)
+	}
+	fmt.Fprintf(r.out, "%s%s\n%s%s\n", indent, strings.Repeat("-", r.amountWidth), indent, total)
+}
+
+// markdownRenderer emits a Markdown table that can be pasted into notes.
+type markdownRenderer struct {
+	out io.Writer
+}
+
+func (r *markdownRenderer) header(title string, amountWidth int) {
+	fmt.Fprintf(r.out, "**%s**\n\n", markdownEscape(title))
+	fmt.Fprintln(r.out, "| ID | Date | Name | Type | Amount | Balance |")
+	fmt.Fprintln(r.out, "|---:|------|------|------|-------:|--------:|")
+}
+
+func (r *markdownRenderer) row(id int, transact db.Transaction, amount, balance string, overdrawn bool) {
+	fmt.Fprintf(r.out, "| %d | %s | %s | %s | %s | %s |\n", id, formatTime(transact.Date), markdownEscape(transact.Name), transact.Type, amount, balance)
+}
+
+func (r *markdownRenderer) footer(total string, overdrawn bool) {
+	fmt.Fprintf(r.out, "| | | **Total** | | | **%s** |\n", total)
+}
+
+// markdownEscape keeps pipes in cells from splitting the row.
+func markdownEscape(s string) string {
+	return strings.Replace(s, "|", "\\|", -1)
+}
+
+// plainRenderer prints undecorated tab-separated rows for scripts.
+type plainRenderer struct {
+	out io.Writer
+}
+
+func (r *plainRenderer) header(title string, amountWidth int) {
+	fmt.Fprintln(r.out, title)
+}
+
+func (r *plainRenderer) row(id int, transact db.Transaction, amount, balance string, overdrawn bool) {
+	fmt.Fprintf(r.out, "%d\t%s\t%s\t%s\t%s\t%s\n", id, formatTime(transact.Date), transact.Name, transact.Type, amount, balance)
+}
+
+func (r *plainRenderer) footer(total string, overdrawn bool) {
+	fmt.Fprintf(r.out, "total\t%s\n", total)
+}
+
+// compactRenderer prints a single short line per transaction without header or total.
+// Its amounts are always signed.
+type compactRenderer struct {
+	out io.Writer
+}
+
+func (r *compactRenderer) header(title string, amountWidth int) {}
+
+func (r *compactRenderer) row(id int, transact db.Transaction, amount, balance string, overdrawn bool) {
+	amount = colorize(amount, amountColor(transact), colorEnabled)
+	fmt.Fprintf(r.out, "#%d %s %s %s\n", id, formatTime(transact.Date), transact.Name, amount)
+}
+
+func (r *compactRenderer) footer(total string, overdrawn bool) {}
+
+// reportTable prints the tables of other commands than transaction listings in the
+// selected style. The ascii style keeps the fixed-width line of each command,
+// the other styles print its cells.
+type reportTable struct {
+	out io.Writer
+}
+
+// newReportTable prints the title and, for markdown, the column names.
+func newReportTable(out io.Writer, title string, columns ...string) *reportTable {
+	if !tableHeaders {
+		return &reportTable{out}
+	}
+	switch tableStyle {
+	case tableStyleMarkdown:
+		fmt.Fprintf(out, "**%s**\n\n", markdownEscape(title))
+		fmt.Fprintf(out, "| %s |\n", strings.Join(columns, " | "))
+		fmt.Fprintf(out, "|%s\n", strings.Repeat("---|", len(columns)))
+	case tableStylePlain:
+		fmt.Fprintln(out, title)
+	case tableStyleCompact:
+	default:
+		fmt.Fprintln(out, getTableHeader(title))
+	}
+	return &reportTable{out}
+}
+
+// row prints the fixed-width line in the ascii style and the cells otherwise.
+func (t *reportTable) row(line string, cells ...string) {
+	switch tableStyle {
+	case tableStyleMarkdown:
+		escaped := make([]string, len(cells))
+		for i, cell := range cells {
+			escaped[i] = markdownEscape(cell)
+		}
+		fmt.Fprintf(t.out, "| %s |\n", strings.Join(escaped, " | "))
+	case tableStylePlain:
+		fmt.Fprintln(t.out, strings.Join(cells, "\t"))
+	case tableStyleCompact:
+		fmt.Fprintln(t.out, strings.Join(cells, " "))
+	default:
+		fmt.Fprintln(t.out, line)
+	}
+}